@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/cluttrdev/deepl-go/deepl"
+
+	"github.com/DeepLcom/deepl-tui/internal/credentials"
+	"github.com/DeepLcom/deepl-tui/internal/ui"
+)
+
+// authInfo describes the authentication key in use.
+type authInfo struct {
+	key string
+	// source describes where the key was found.
+	source string
+	// serverURL is the configured server URL, empty for the default one.
+	serverURL string
+}
+
+// isFreeKey reports whether the key is a DeepL API Free key, which ends in
+// `:fx`.
+func isFreeKey(key string) bool {
+	return strings.HasSuffix(key, ":fx")
+}
+
+// endpoint returns the URL of the API server used with the key.
+func (a authInfo) endpoint() string {
+	switch {
+	case a.serverURL != "":
+		return strings.TrimRight(a.serverURL, "/")
+	case isFreeKey(a.key):
+		return deepl.ServerURLFree
+	default:
+		return deepl.ServerURLPro
+	}
+}
+
+// warning returns a warning if a Free key is used with the Pro API server or
+// vice versa.
+func (a authInfo) warning() string {
+	endpoint := a.endpoint()
+	switch {
+	case isFreeKey(a.key) && strings.HasPrefix(endpoint, deepl.ServerURLPro):
+		return "Free API keys (ending in :fx) only work with " + deepl.ServerURLFree
+	case !isFreeKey(a.key) && strings.HasPrefix(endpoint, deepl.ServerURLFree):
+		return "Pro API keys do not work with " + deepl.ServerURLFree + ", use " + deepl.ServerURLPro
+	}
+	return ""
+}
+
+// setupAccountPage provides the account page with the key information and
+// requests the usage whenever the page is shown.
+func (app *Application) setupAccountPage() {
+	app.ui.SetAccountRefreshFunc(func() {
+		app.ui.SetAccountInfo(app.accountInfo(nil, nil))
+		go func() {
+			usage, err := app.translator.GetUsage()
+			app.ui.QueueUpdateDraw(func() {
+				app.ui.SetAccountInfo(app.accountInfo(usage, err))
+			})
+		}()
+	})
+
+	if warning := app.auth.warning(); warning != "" {
+		app.ui.SetFooter(warning)
+	}
+}
+
+func (app *Application) accountInfo(usage *deepl.Usage, err error) ui.AccountInfo {
+	return ui.AccountInfo{
+		Free:         isFreeKey(app.auth.key),
+		Key:          credentials.Mask(app.auth.key),
+		KeySource:    app.auth.source,
+		ServerURL:    app.auth.endpoint(),
+		Warning:      app.auth.warning(),
+		Usage:        usage,
+		UsageErr:     err,
+		Glossaries:   len(app.glossaries.List()),
+		Multilingual: app.multilingual,
+	}
+}
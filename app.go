@@ -1,14 +1,29 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"github.com/cluttrdev/deepl-go/deepl"
 
+	"github.com/DeepLcom/deepl-tui/internal/api"
+	"github.com/DeepLcom/deepl-tui/internal/cache"
+	"github.com/DeepLcom/deepl-tui/internal/clipboard"
+	"github.com/DeepLcom/deepl-tui/internal/config"
+	"github.com/DeepLcom/deepl-tui/internal/deeplv3"
 	"github.com/DeepLcom/deepl-tui/internal/handlers"
+	"github.com/DeepLcom/deepl-tui/internal/paragraphs"
+	"github.com/DeepLcom/deepl-tui/internal/subtitles"
 	"github.com/DeepLcom/deepl-tui/internal/ui"
 )
 
@@ -16,6 +31,7 @@ import (
 type Application struct {
 	ui         *ui.UI
 	translator *deepl.Translator
+	config     config.Config
 
 	textChanged chan struct{}
 
@@ -25,21 +41,66 @@ type Application struct {
 	targetLang  string
 
 	formality string
+	markdown  bool
+	subtitles bool // whether the input was loaded from a subtitle file
 
-	glossaries handlers.GlossariesHandler
-	glossaryID string
+	translationSeq int    // incremented for every translation request
+	translatedText string // input of the last translation request
+	manual         bool   // whether the input is only translated on request
+	confirmed      int    // number of characters of a large input the user confirmed
+	paragraphs     *paragraphs.Cache
+
+	glossaries     handlers.GlossariesHandler
+	glossaryClient *deeplv3.Client
+	multilingual   bool // whether the v3 glossary API is available
+	glossaryID     string
+	glossaryPair   string // language pair for which the default glossary was applied
+
+	clipboard clipboard.Backend
+	watch     *clipboardWatch
+
+	auth authInfo
+
+	cache      cache.Data // languages and glossaries for offline use
+	cachePath  string
+	refreshing atomic.Bool // whether a refresh is in progress
 }
 
 // NewApplication creates and returns a new apllication.
-func NewApplication(t *deepl.Translator) *Application {
+func NewApplication(t *deepl.Translator, g *deeplv3.Client, cfg config.Config, auth authInfo) (*Application, error) {
+	cb, err := clipboard.New(cfg.Clipboard)
+	if err != nil {
+		return nil, err
+	}
+
 	tui := ui.NewUI()
 	tui.EnableMouse(true)
 	tui.EnablePaste(false)
+	tui.SetClipboardBackend(cb)
+	tui.SetLogFile(cfg.Log.File)
+
+	var manual bool
+	switch cfg.Translation.Mode {
+	case "", "auto":
+	case "manual":
+		manual = true
+	default:
+		return nil, fmt.Errorf("invalid translation mode: %s", cfg.Translation.Mode)
+	}
 
 	return &Application{
 		ui:         tui,
 		translator: t,
-	}
+		config:     cfg,
+		markdown:   cfg.Markdown,
+		manual:     manual,
+		paragraphs: paragraphs.NewCache(cfg.Translation.ContextParagraphs),
+
+		glossaryClient: g,
+		clipboard:      cb,
+		auth:           auth,
+		cachePath:      cache.DefaultPath(auth.key, auth.serverURL),
+	}, nil
 }
 
 // Run initializes the application and runs the main loop.
@@ -47,22 +108,34 @@ func (app *Application) Run() error {
 	app.textChanged = make(chan struct{})
 	defer close(app.textChanged)
 
-	if err := app.setLanguageOptions(); err != nil {
-		app.ui.SetFooter(err.Error())
-	}
-
 	if err := app.setFormalityOptions(); err != nil {
 		app.ui.SetFooter(err.Error())
 	}
 
 	app.setupGlossaryHandling()
+	app.setupClipboardWatch(app.config.ClipboardWatch)
+	app.setupMarkdownMode()
+	app.ui.SetFileOpenedFunc(func(path string) {
+		// only the input of subtitle files is translated as subtitles
+		app.subtitles = subtitles.IsFile(path)
+	})
+	app.setupTranslateMode()
+	app.setupAccountPage()
 
 	app.ui.SetInputTextChangedFunc(func() {
 		app.textChanged <- struct{}{}
 	})
 
+	// start with cached data if available, the remote data is loaded in the
+	// background so that the ui shows up right away
+	if !app.loadCache() {
+		app.ui.SetLangOptionsPlaceholder("Loading languages...")
+		app.ui.SetStatus("Loading languages and glossaries...")
+	}
+	app.setupRefresh(time.Duration(app.config.RefreshInterval))
+
 	go func() {
-		period := 500 * time.Millisecond
+		period := max(time.Duration(app.config.Translation.Delay), 10*time.Millisecond)
 		ticker := time.NewTicker(period)
 
 		var changed bool
@@ -70,13 +143,15 @@ func (app *Application) Run() error {
 			select {
 			case _, ok := <-app.textChanged:
 				if !ok {
-					break
+					return
 				}
 				changed = true
 				ticker.Reset(period)
 			case <-ticker.C:
 				if changed {
-					app.updateTranslation()
+					// don't block, the event loop may be waiting to send
+					// the next change
+					go app.ui.QueueUpdateDraw(app.inputChanged)
 					changed = false
 				}
 			}
@@ -91,15 +166,57 @@ func (app *Application) Run() error {
 }
 
 func (app *Application) setError(err error) {
+	slog.Error("error", "error", err.Error())
 	app.ui.SetFooter(fmt.Sprintf("Error: %v", err))
 }
 
-func (app *Application) setLanguageOptions() error {
-	sourceLangs, err := app.translator.GetLanguages("source")
-	if err != nil {
-		return fmt.Errorf("error getting source languages: %w", err)
+// fetchLanguages retrieves the supported source and target languages.
+func (app *Application) fetchLanguages() ([]deepl.Language, []deepl.Language, error) {
+	var (
+		sourceLangs, targetLangs []deepl.Language
+		sourceErr, targetErr     error
+		wg                       sync.WaitGroup
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		sourceLangs, sourceErr = app.translator.GetLanguages("source")
+	}()
+	go func() {
+		defer wg.Done()
+		targetLangs, targetErr = app.translator.GetLanguages("target")
+	}()
+	wg.Wait()
+
+	if sourceErr != nil {
+		return nil, nil, fmt.Errorf("error getting source languages: %w", sourceErr)
+	}
+	if targetErr != nil {
+		return nil, nil, fmt.Errorf("error getting target languages: %w", targetErr)
+	}
+	return sourceLangs, targetLangs, nil
+}
+
+// setLanguages sets the language options unless they did not change.
+func (app *Application) setLanguages(sourceLangs []deepl.Language, targetLangs []deepl.Language) {
+	if app.sourceLangs == nil ||
+		!slices.Equal(sourceLangs, app.cache.Languages.Source) ||
+		!slices.Equal(targetLangs, app.cache.Languages.Target) {
+		app.setLanguageOptions(sourceLangs, targetLangs)
 	}
 
+	app.cache.Languages = cache.Languages{
+		Source:  sourceLangs,
+		Target:  targetLangs,
+		Updated: time.Now(),
+	}
+}
+
+// setLanguageOptions sets the language options, keeping the selected
+// languages if they are still supported.
+func (app *Application) setLanguageOptions(sourceLangs []deepl.Language, targetLangs []deepl.Language) {
+	sourceLang, targetLang := app.sourceLang, app.targetLang
+
 	app.sourceLangs = make([]string, 1, len(sourceLangs)+1)
 	var sourceLangOpts = make([]string, 1, len(sourceLangs)+1)
 	sourceLangOpts[0] = "Detect language"
@@ -111,16 +228,12 @@ func (app *Application) setLanguageOptions() error {
 	app.ui.SetSourceLangOptions(
 		sourceLangOpts,
 		func(text string, index int) {
-			app.sourceLang = app.sourceLangs[index]
-			app.updateTranslation()
+			app.sourceLang = app.sourceLangs[max(index, 0)]
+			app.updateGlossaryDialogOptions()
+			app.autoTranslate()
 		},
 	)
 
-	targetLangs, err := app.translator.GetLanguages("target")
-	if err != nil {
-		return fmt.Errorf("error getting target languages: %w", err)
-	}
-
 	app.targetLangs = make([]string, 0, len(targetLangs))
 	var targetLangOpts = make([]string, 0, len(targetLangs))
 	for _, lang := range targetLangs {
@@ -131,12 +244,21 @@ func (app *Application) setLanguageOptions() error {
 	app.ui.SetTargetLangOptions(
 		targetLangOpts,
 		func(text string, index int) {
-			app.targetLang = app.targetLangs[index]
-			app.updateTranslation()
+			app.targetLang = ""
+			if index >= 0 {
+				app.targetLang = app.targetLangs[index]
+			}
+			app.updateGlossaryDialogOptions()
+			app.autoTranslate()
 		},
 	)
 
-	return nil
+	if i := slices.Index(app.sourceLangs, sourceLang); i > 0 {
+		app.ui.SelectSourceLang(i)
+	}
+	if targetLang != "" {
+		app.ui.SelectTargetLang(slices.Index(app.targetLangs, targetLang))
+	}
 }
 
 func (app *Application) setFormalityOptions() error {
@@ -151,7 +273,7 @@ func (app *Application) setFormalityOptions() error {
 			case "Informal tone":
 				app.formality = "prefer_less"
 			}
-			app.updateTranslation()
+			app.autoTranslate()
 		},
 	)
 
@@ -159,33 +281,40 @@ func (app *Application) setFormalityOptions() error {
 }
 
 func (app *Application) setupGlossaryHandling() {
-	if err := app.updateGlossaries(); err != nil {
-		app.ui.SetFooter(err.Error())
-	}
-
 	app.ui.SetGlossaryDataFunc(func(id string) (deepl.GlossaryInfo, []deepl.GlossaryEntry) {
 		info, ok := app.glossaries.Get(id)
 		if !ok {
 			return info, nil
 		}
-		entries, ok := app.glossaries.Entries(id)
-		if !ok {
-			var err error
-			entries, err = app.glossaries.FetchEntries(app.translator, id)
-			if err != nil {
-				app.ui.SetFooter(err.Error())
-			}
+		// a multilingual glossary has a dictionary for each language pair,
+		// show the one used for the selected languages
+		if dict, ok := app.glossaries.DictionaryFor(id, app.sourceLang, app.targetLang); ok {
+			info.SourceLang, info.TargetLang, info.EntryCount = dict.SourceLang, dict.TargetLang, dict.EntryCount
+		}
+		entries, err := app.glossaryEntries(id, info.SourceLang, info.TargetLang)
+		if err != nil {
+			app.ui.SetFooter(err.Error())
 		}
 		return info, entries
 	})
 
+	app.ui.SetGlossaryDictionariesFunc(app.glossaries.Dictionaries)
+
+	app.ui.SetGlossaryDictionaryDataFunc(func(id string, source string, target string) []deepl.GlossaryEntry {
+		entries, err := app.glossaryEntries(id, source, target)
+		if err != nil {
+			app.ui.SetFooter(err.Error())
+		}
+		return entries
+	})
+
 	app.ui.SetGlossarySelectedFunc(func(id string) {
-		app.glossaryID = id
-		app.updateTranslation()
+		app.setGlossary(id)
+		app.autoTranslate()
 	})
 
 	app.ui.SetGlossaryCreateFunc(func(name string, source string, target string, entries [][2]string) {
-		if err := app.glossaries.Create(app.translator, name, source, target, entries); err != nil {
+		if _, err := app.glossaries.Create(app.translator, name, source, target, entries); err != nil {
 			app.ui.SetFooter(err.Error())
 			return
 		}
@@ -195,18 +324,48 @@ func (app *Application) setupGlossaryHandling() {
 		}
 	})
 
-	app.ui.SetGlossaryUpdateFunc(func(id string, name string, entries [][2]string) {
+	app.ui.SetGlossaryUpdateFunc(func(id string, name string, source string, target string, entries [][2]string) {
+		if err := app.updateGlossary(id, name, source, target, entries); err != nil {
+			app.ui.SetFooter(err.Error())
+		}
+	})
+
+	app.ui.SetGlossaryEntryAddFunc(func(id string, source string, target string) {
 		info, ok := app.glossaries.Get(id)
 		if !ok {
 			app.ui.SetFooter(fmt.Sprintf("Unknown glossary id: %s", id))
 			return
 		}
 
-		if err := app.glossaries.Create(app.translator, name, info.SourceLang, info.TargetLang, entries); err != nil {
+		dict, ok := app.glossaries.DictionaryFor(id, app.sourceLang, app.targetLang)
+		if !ok {
+			app.ui.SetFooter(fmt.Sprintf("Glossary %q does not match the selected languages", info.Name))
+			return
+		}
+
+		entries, err := app.glossaryEntries(id, dict.SourceLang, dict.TargetLang)
+		if err != nil {
 			app.ui.SetFooter(err.Error())
 			return
 		}
 
+		pairs := make([][2]string, 0, len(entries)+1)
+		for _, entry := range entries {
+			if entry.Source != source {
+				pairs = append(pairs, [2]string{entry.Source, entry.Target})
+			}
+		}
+		pairs = append(pairs, [2]string{source, target})
+
+		if err := app.updateGlossary(id, info.Name, dict.SourceLang, dict.TargetLang, pairs); err != nil {
+			app.ui.SetFooter(err.Error())
+			return
+		}
+		app.ui.SetFooter(fmt.Sprintf("Added entry to glossary %q", info.Name))
+		app.autoTranslate()
+	})
+
+	app.ui.SetGlossaryDeleteFunc(func(id string) {
 		if err := app.glossaries.Delete(app.translator, id); err != nil {
 			app.ui.SetFooter(err.Error())
 		}
@@ -216,8 +375,8 @@ func (app *Application) setupGlossaryHandling() {
 		}
 	})
 
-	app.ui.SetGlossaryDeleteFunc(func(id string) {
-		if err := app.glossaries.Delete(app.translator, id); err != nil {
+	app.ui.SetGlossaryDictionaryDeleteFunc(func(id string, source string, target string) {
+		if err := app.glossaries.DeleteDictionary(app.glossaryClient, id, source, target); err != nil {
 			app.ui.SetFooter(err.Error())
 		}
 
@@ -227,51 +386,364 @@ func (app *Application) setupGlossaryHandling() {
 	})
 }
 
+// cachedGlossaryEntries returns the entries of a glossary dictionary if they
+// have been loaded before.
+func (app *Application) cachedGlossaryEntries(id string, source string, target string) ([]deepl.GlossaryEntry, bool) {
+	if app.multilingual {
+		return app.glossaries.DictionaryEntries(id, source, target)
+	}
+	return app.glossaries.Entries(id)
+}
+
+// glossaryEntries returns the entries of the glossary dictionary for the
+// given languages and fetches them if necessary.
+func (app *Application) glossaryEntries(id string, source string, target string) ([]deepl.GlossaryEntry, error) {
+	if entries, ok := app.cachedGlossaryEntries(id, source, target); ok {
+		return entries, nil
+	}
+
+	var (
+		entries []deepl.GlossaryEntry
+		err     error
+	)
+	if app.multilingual {
+		entries, err = app.glossaries.FetchDictionaryEntries(app.glossaryClient, id, source, target)
+	} else {
+		entries, err = app.glossaries.FetchEntries(app.translator, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	app.saveCache()
+	return entries, nil
+}
+
+// updateGlossary sets the name of a glossary and the entries of its
+// dictionary for the given languages.
+//
+// If the v3 glossary API is available, the glossary is modified in place.
+// Otherwise it is replaced by a new one, in which case the new glossary is
+// used for translations instead of the old one.
+func (app *Application) updateGlossary(id string, name string, source string, target string, entries [][2]string) error {
+	info, ok := app.glossaries.Get(id)
+	if !ok {
+		return fmt.Errorf("Unknown glossary id: %s", id)
+	}
+
+	if app.multilingual {
+		if err := app.glossaries.UpdateDictionary(app.glossaryClient, id, name, source, target, entries); err != nil {
+			return err
+		}
+		app.paragraphs.Clear()
+		if err := app.updateGlossaries(); err != nil {
+			return err
+		}
+		if app.glossaryID == id {
+			app.setGlossary(id)
+		}
+		return nil
+	}
+
+	if source != info.SourceLang || target != info.TargetLang {
+		return errors.New("Glossary languages cannot be changed")
+	}
+
+	created, err := app.glossaries.Create(app.translator, name, info.SourceLang, info.TargetLang, entries)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if err := app.glossaries.Delete(app.translator, id); err != nil {
+		errs = append(errs, err)
+	}
+
+	if app.glossaryID == id {
+		app.glossaryID = created.GlossaryId
+	}
+
+	if err := app.updateGlossaries(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if app.glossaryID == created.GlossaryId {
+		app.setGlossary(app.glossaryID)
+	}
+
+	return errors.Join(errs...)
+}
+
+// setupMarkdownMode registers the command toggling Markdown mode.
+func (app *Application) setupMarkdownMode() {
+	app.ui.SetCommandFunc("markdown", func(args []string) error {
+		switch {
+		case len(args) == 0:
+			app.markdown = !app.markdown
+		case len(args) == 1 && args[0] == "on":
+			app.markdown = true
+		case len(args) == 1 && args[0] == "off":
+			app.markdown = false
+		default:
+			return errors.New("usage: markdown [on|off]")
+		}
+
+		app.autoTranslate()
+		if app.markdown {
+			return errors.New("Markdown mode on")
+		}
+		return errors.New("Markdown mode off")
+	})
+}
+
+// translateSettings returns the currently selected translate settings.
+func (app *Application) translateSettings() handlers.TranslateSettings {
+	return handlers.TranslateSettings{
+		SourceLang: app.sourceLang,
+		TargetLang: app.targetLang,
+		Formality:  app.formality,
+		GlossaryID: app.glossaryID,
+	}
+}
+
 func (app *Application) updateTranslation() {
 	go func() {
-		app.ui.QueueUpdateDraw(func() {
-			app.ui.ClearOutputText()
+		var (
+			text       string
+			targetLang string
+			markdown   bool
+			subs       bool
+			opts       []deepl.TranslateOption
+			seq        int
+			characters int
+			confirm    bool
+		)
+		app.ui.QueueUpdate(func() {
+			app.translationSeq++
+			seq = app.translationSeq
+
+			text = app.ui.GetInputText()
+			app.translatedText = text
+			settings := app.translateSettings()
+			targetLang = settings.TargetLang
+			markdown = app.markdown
+			subs = app.subtitles
+			opts = settings.Options(&app.glossaries)
+
+			characters = app.billedCharacters(text, targetLang, markdown, subs, opts)
+			confirm = app.needsConfirmation(characters)
+		})
 
-			text := app.ui.GetInputText()
-			if text == "" {
-				return
-			} else if app.targetLang == "" {
+		if text == "" {
+			app.ui.QueueUpdateDraw(app.ui.ClearOutputText)
+			return
+		} else if targetLang == "" {
+			app.ui.QueueUpdateDraw(func() {
+				app.ui.ClearOutputText()
 				app.setError(fmt.Errorf("Target language not set"))
+			})
+			return
+		}
+
+		if confirm {
+			app.confirmTranslation(seq, characters)
+			return
+		}
+
+		// the network request is done outside of the event loop, so that the
+		// user interface stays responsive while requests are retried
+		output, err := app.translate(text, targetLang, markdown, subs, opts)
+
+		app.ui.QueueUpdateDraw(func() {
+			if seq != app.translationSeq {
+				// the input changed in the meantime
 				return
 			}
 
-			var opts []deepl.TranslateOption
-			if app.sourceLang != "" {
-				opts = append(opts, deepl.WithSourceLang(app.sourceLang))
-			}
-			if app.formality != "" {
-				opts = append(opts, deepl.WithFormality(app.formality))
-			}
-			if app.glossaryID != "" {
-				opts = append(opts, deepl.WithGlossaryID(app.glossaryID))
+			app.ui.ClearOutputText()
+			if err != nil {
+				// translate the same input again after the next change
+				app.translatedText = ""
+				app.setError(err)
+				return
 			}
 
-			translations, err := app.translator.TranslateText([]string{text}, app.targetLang, opts...)
-			if err != nil {
+			if err := app.ui.WriteOutputText(strings.NewReader(output)); err != nil {
 				app.setError(err)
 				return
 			}
 
-			for _, translation := range translations {
-				if err := app.ui.WriteOutputText(strings.NewReader(translation.Text)); err != nil {
-					app.setError(err)
-					return
-				}
+			if err := app.watch.translated(text, app.ui.GetOutputText()); err != nil {
+				app.setError(err)
 			}
 		})
 	}()
 }
 
-func (app *Application) updateGlossaries() (err error) {
-	if err := app.glossaries.FetchGlossaries(app.translator); err != nil {
+// needsConfirmation reports whether the translation of an input with the
+// given number of characters has to be confirmed. Once confirmed, a large input
+// can grow by another `confirm_characters` before the next confirmation.
+func (app *Application) needsConfirmation(characters int) bool {
+	threshold := app.config.ConfirmCharacters
+	if threshold <= 0 {
+		return false
+	}
+	if characters < threshold {
+		app.confirmed = 0
+		return false
+	}
+	return app.confirmed == 0 || characters-app.confirmed >= threshold
+}
+
+// confirmTranslation requests the usage and asks the user to confirm the
+// translation of a large input. It must not be called on the event loop.
+func (app *Application) confirmTranslation(seq int, characters int) {
+	usage, err := app.translator.GetUsage()
+	app.ui.QueueUpdateDraw(func() {
+		if seq != app.translationSeq {
+			// the input changed in the meantime
+			return
+		}
+		app.ui.ConfirmTranslation(characters, usage, err, func(ok bool) {
+			if !ok {
+				app.ui.SetFooter(fmt.Sprintf("Translation of %d characters cancelled", characters))
+				return
+			}
+			app.confirmed = characters
+			app.updateTranslation()
+		})
+	})
+}
+
+// translate translates the input text. Subtitles loaded from a file are
+// translated cue by cue; in Markdown mode only the prose is translated.
+func (app *Application) translate(text string, targetLang string, markdown bool, subtitleFile bool, opts []deepl.TranslateOption) (string, error) {
+	if subtitleFile {
+		if subs, err := subtitles.Parse(text); err == nil {
+			sopts := subtitles.DefaultOptions()
+			sopts.Merge = app.config.Subtitles.Merge
+			if err := handlers.TranslateSubtitles(app.translator, subs, targetLang, sopts, opts...); err != nil {
+				return "", err
+			}
+			return subs.String(), nil
+		}
+	}
+
+	if markdown {
+		return handlers.TranslateMarkdown(app.translator, text, targetLang, opts...)
+	}
+
+	// only the paragraphs that changed since the last translation are sent
+	return app.paragraphs.Translate(text, translationKey(targetLang, opts), handlers.TextTranslator(app.translator, targetLang, opts...))
+}
+
+// billedCharacters returns the number of characters billed for translating
+// the text. For plain text, cached paragraphs are not translated again.
+func (app *Application) billedCharacters(text string, targetLang string, markdown bool, subtitleFile bool, opts []deepl.TranslateOption) int {
+	if markdown {
+		return utf8.RuneCountInString(text)
+	}
+	if subtitleFile {
+		if _, err := subtitles.Parse(text); err == nil {
+			return utf8.RuneCountInString(text)
+		}
+	}
+	return app.paragraphs.Estimate(text, translationKey(targetLang, opts))
+}
+
+// translationKey identifies the target language and translate options under
+// which translated paragraphs are cached.
+func translationKey(targetLang string, opts []deepl.TranslateOption) string {
+	var o deepl.TranslateOptions
+	_ = o.Gather(opts...)
+	data, _ := json.Marshal(o)
+	return targetLang + " " + string(data)
+}
+
+// notifyRetry tells the user that a failed request is retried.
+func (app *Application) notifyRetry(err *api.Error, attempt int, delay time.Duration) {
+	// requests may be sent from the event loop, so don't wait for the update
+	go app.ui.QueueUpdateDraw(func() {
+		app.ui.SetFooter(fmt.Sprintf("%v, retrying in %s (attempt %d)", err, delay.Round(100*time.Millisecond), attempt))
+	})
+}
+
+// updateGlossaries fetches the glossaries and supported glossary languages
+// and updates the glossary options.
+func (app *Application) updateGlossaries() error {
+	state, multilingual, err := app.fetchGlossaries()
+	if err != nil {
 		return err
 	}
 
+	app.setGlossaries(state, multilingual)
+	app.saveCache()
+	return nil
+}
+
+// fetchGlossaries retrieves the glossaries, their dictionaries and the
+// supported glossary language pairs. It reports whether the v3 glossary API
+// is available.
+func (app *Application) fetchGlossaries() (handlers.GlossariesState, bool, error) {
+	var (
+		h, pairs     handlers.GlossariesHandler
+		multilingual bool
+		errs         [2]error
+		wg           sync.WaitGroup
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if errs[0] = h.FetchGlossaries(app.translator); errs[0] != nil {
+			return
+		}
+
+		// multilingual glossaries are optional, fall back to the v2 API if
+		// the v3 API is not available
+		multilingual = app.glossaryClient != nil &&
+			h.FetchDictionaries(app.glossaryClient) == nil
+	}()
+	go func() {
+		defer wg.Done()
+		errs[1] = pairs.FetchLanguages(app.translator)
+	}()
+	wg.Wait()
+
+	if err := errors.Join(errs[:]...); err != nil {
+		return handlers.GlossariesState{}, false, err
+	}
+
+	state := h.State()
+	state.Languages = pairs.State().Languages
+	return state, multilingual, nil
+}
+
+// setGlossaries replaces the glossaries by freshly fetched ones. The
+// glossary options are only updated if the glossaries changed, so that a
+// refresh does not reset the glossaries page.
+func (app *Application) setGlossaries(state handlers.GlossariesState, multilingual bool) {
+	current := app.glossaries.State()
+	changed := app.cache.Glossaries.Updated.IsZero() ||
+		multilingual != app.multilingual ||
+		!slices.Equal(state.Glossaries, current.Glossaries) ||
+		!slices.Equal(state.Languages, current.Languages) ||
+		!reflect.DeepEqual(state.Dictionaries, current.Dictionaries)
+
+	app.glossaries.Refresh(state)
+	app.multilingual = multilingual
+	app.cache.Glossaries.Updated = time.Now()
+	if changed {
+		// glossary entries may have changed without a new glossary id
+		app.paragraphs.Clear()
+		app.setGlossaryOptions()
+	}
+}
+
+// setGlossaryOptions shows the glossaries and the supported glossary
+// languages in the ui.
+func (app *Application) setGlossaryOptions() {
+	app.ui.SetGlossaryMultilingual(app.multilingual)
+
 	var opts [][2]string
 	for _, info := range app.glossaries.List() {
 		opts = append(opts, [2]string{info.GlossaryId, info.Name})
@@ -283,15 +755,243 @@ func (app *Application) updateGlossaries() (err error) {
 	})
 
 	app.ui.SetGlossaryOptions(opts)
+	app.updateGlossaryDialogOptions()
 
-	// >>>>>>>>
-	if err := app.glossaries.FetchLanguages(app.translator); err != nil {
-		return err
-	}
 	langs := app.glossaries.GetSourceLangs("")
-
+	sort.Strings(langs)
 	app.ui.SetGlossaryLanguageOptions(langs)
-	// <<<<<<<<
+}
 
-	return nil
+// loadCache shows the cached languages and glossaries. It reports whether
+// cached data is available.
+func (app *Application) loadCache() bool {
+	data, err := cache.Load(app.cachePath)
+	if err != nil {
+		app.setError(err)
+		return false
+	}
+	app.cache = data
+
+	if data.Languages.Updated.IsZero() || data.Glossaries.Updated.IsZero() {
+		return false
+	}
+
+	app.setLanguageOptions(data.Languages.Source, data.Languages.Target)
+	app.glossaries.Restore(data.Glossaries.GlossariesState)
+	app.multilingual = data.Glossaries.Multilingual
+	app.setGlossaryOptions()
+
+	app.ui.SetStatus("Cached data from " + cache.Age(app.cacheUpdated()))
+	return true
+}
+
+// setupRefresh registers the command refreshing the languages and
+// glossaries, starts the initial refresh and refreshes periodically if
+// `interval` is positive.
+func (app *Application) setupRefresh(interval time.Duration) {
+	app.ui.SetCommandFunc("refresh", func(args []string) error {
+		if len(args) > 0 {
+			return errors.New("usage: refresh")
+		}
+		if !app.refreshing.CompareAndSwap(false, true) {
+			return errors.New("Refresh in progress")
+		}
+		go app.refresh()
+		return errors.New("Refreshing languages and glossaries")
+	})
+
+	app.refreshing.Store(true)
+	go app.refresh()
+
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		for range time.Tick(interval) {
+			if app.refreshing.CompareAndSwap(false, true) {
+				app.refresh()
+			}
+		}
+	}()
+}
+
+// refresh fetches the languages and glossaries concurrently and replaces the
+// shown and cached data. If the data cannot be fetched, the cached data is
+// marked as outdated. It must be called with `app.refreshing` set and is
+// meant to run outside of the event loop.
+func (app *Application) refresh() {
+	defer app.refreshing.Store(false)
+
+	var (
+		sourceLangs, targetLangs []deepl.Language
+		langErr                  error
+		state                    handlers.GlossariesState
+		multilingual             bool
+		glossaryErr              error
+		wg                       sync.WaitGroup
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		sourceLangs, targetLangs, langErr = app.fetchLanguages()
+	}()
+	go func() {
+		defer wg.Done()
+		state, multilingual, glossaryErr = app.fetchGlossaries()
+	}()
+	wg.Wait()
+
+	app.ui.QueueUpdateDraw(func() {
+		if langErr == nil {
+			app.setLanguages(sourceLangs, targetLangs)
+		} else if app.sourceLangs == nil {
+			app.ui.SetLangOptionsPlaceholder("Languages unavailable")
+		}
+		if glossaryErr == nil {
+			app.setGlossaries(state, multilingual)
+		}
+		app.saveCache()
+
+		if err := errors.Join(langErr, glossaryErr); err != nil {
+			if t := app.cacheUpdated(); t.IsZero() {
+				app.ui.SetStatus("Offline")
+			} else {
+				app.ui.SetStatus("Offline, data from " + cache.Age(t))
+			}
+			app.setError(err)
+			return
+		}
+		app.ui.SetStatus("")
+	})
+}
+
+// cacheUpdated returns when the oldest part of the cached data was fetched.
+func (app *Application) cacheUpdated() time.Time {
+	t := app.cache.Languages.Updated
+	if u := app.cache.Glossaries.Updated; u.Before(t) {
+		t = u
+	}
+	return t
+}
+
+// saveCache persists the languages and glossaries.
+func (app *Application) saveCache() {
+	app.cache.Glossaries.GlossariesState = app.glossaries.State()
+	app.cache.Glossaries.Multilingual = app.multilingual
+	if err := cache.Save(app.cachePath, app.cache); err != nil {
+		app.setError(fmt.Errorf("error writing cache: %w", err))
+	}
+}
+
+// updateGlossaryDialogOptions restricts the glossaries that can be selected
+// on the translate page to the ones compatible with the current language pair.
+// An incompatible glossary selection is cleared and the configured default
+// glossary is selected when the language pair changes.
+func (app *Application) updateGlossaryDialogOptions() {
+	var opts [][2]string
+	for _, info := range app.glossaries.Compatible(app.sourceLang, app.targetLang) {
+		opts = append(opts, [2]string{info.GlossaryId, info.Name})
+	}
+
+	// sort by name
+	sort.SliceStable(opts, func(i, j int) bool {
+		return opts[i][1] < opts[j][1]
+	})
+
+	app.ui.SetGlossaryDialogOptions(opts)
+
+	compatible := func(id string) bool {
+		for _, opt := range opts {
+			if opt[0] == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	if app.glossaryID != "" && !compatible(app.glossaryID) {
+		info, _ := app.glossaries.Get(app.glossaryID)
+		app.setGlossary("")
+		app.ui.SetFooter(fmt.Sprintf("Glossary %q cleared, it does not match the selected languages", info.Name))
+	}
+
+	source := handlers.GlossaryLang(app.sourceLang)
+	target := handlers.GlossaryLang(app.targetLang)
+	if pair := source + "-" + target; pair != app.glossaryPair {
+		app.glossaryPair = pair
+		if app.glossaryID == "" {
+			if name := app.config.DefaultGlossary(source, target); name != "" {
+				id := name
+				if _, ok := app.glossaries.Get(id); !ok {
+					id = app.glossaries.FindName(name)
+				}
+				if compatible(id) {
+					app.setGlossary(id)
+					app.ui.SetFooter(fmt.Sprintf("Using default glossary %q", name))
+				}
+			}
+		}
+	}
+
+	app.ui.SelectGlossary(app.glossaryID)
+}
+
+// setGlossary sets the glossary used for translations and shows it in the ui.
+func (app *Application) setGlossary(id string) {
+	app.glossaryID = id
+
+	info, ok := app.glossaries.Get(id)
+	if !ok {
+		app.ui.SetActiveGlossary("", nil)
+		return
+	}
+
+	dict, ok := app.glossaries.DictionaryFor(id, app.sourceLang, app.targetLang)
+	if !ok {
+		app.ui.SetActiveGlossary(info.Name, nil)
+		return
+	}
+	if entries, ok := app.cachedGlossaryEntries(id, dict.SourceLang, dict.TargetLang); ok {
+		app.ui.SetActiveGlossary(info.Name, entries)
+		return
+	}
+
+	// show the name right away and highlight the terms once the entries are
+	// loaded, the request is done outside of the event loop
+	app.ui.SetActiveGlossary(info.Name, nil)
+	multilingual := app.multilingual
+	go func() {
+		var (
+			h       handlers.GlossariesHandler
+			entries []deepl.GlossaryEntry
+			err     error
+		)
+		if multilingual {
+			entries, err = h.FetchDictionaryEntries(app.glossaryClient, id, dict.SourceLang, dict.TargetLang)
+		} else {
+			entries, err = h.FetchEntries(app.translator, id)
+		}
+
+		app.ui.QueueUpdateDraw(func() {
+			if err != nil {
+				app.ui.SetFooter(err.Error())
+				return
+			}
+			if multilingual != app.multilingual {
+				return
+			}
+			if multilingual {
+				app.glossaries.SetDictionaryEntries(id, dict.SourceLang, dict.TargetLang, entries)
+			} else {
+				app.glossaries.SetEntries(id, entries)
+			}
+			app.saveCache()
+
+			// the glossary or the languages may have changed in the meantime
+			if current, ok := app.glossaries.DictionaryFor(app.glossaryID, app.sourceLang, app.targetLang); ok &&
+				app.glossaryID == id && current.SourceLang == dict.SourceLang && current.TargetLang == dict.TargetLang {
+				app.ui.SetActiveGlossary(info.Name, entries)
+			}
+		})
+	}()
 }
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/DeepLcom/deepl-tui/internal/config"
+	"github.com/DeepLcom/deepl-tui/internal/credentials"
+)
+
+const authUsage = "usage: deepl-tui auth login [--store auto|keyring|file] | logout | status"
+
+func runAuth(cfg config.Config, args []string) error {
+	if len(args) < 1 {
+		return errors.New(authUsage)
+	}
+
+	switch args[0] {
+	case "login":
+		return runAuthLogin(cfg, args[1:])
+	case "logout":
+		return runAuthLogout(args[1:])
+	case "status":
+		return runAuthStatus(cfg, args[1:])
+	default:
+		return fmt.Errorf("unknown auth command: %s", args[0])
+	}
+}
+
+// runAuthLogin reads the authentication key, verifies it and stores it in
+// the keyring or the encrypted credentials file.
+func runAuthLogin(cfg config.Config, args []string) error {
+	flags := flag.NewFlagSet("auth login", flag.ContinueOnError)
+	storeName := flags.String("store", "auto", "where to store the key, one of auto, keyring or file.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New(authUsage)
+	}
+
+	store, err := credentials.New(*storeName, readPassphrase)
+	if err != nil {
+		return err
+	}
+
+	key, err := readSecret("DeepL authentication key: ")
+	if err != nil {
+		return err
+	}
+	if key == "" {
+		return errors.New("no authentication key entered")
+	}
+
+	if err := verifyAuthKey(cfg, key); err != nil {
+		return err
+	}
+
+	err = store.Set(key)
+	if err != nil && *storeName == "auto" && store.Name() == "keyring" {
+		// the keyring may be unavailable, e.g. without a desktop session
+		fmt.Fprintf(os.Stderr, "Error storing key in keyring: %v\n", err)
+		store, _ = credentials.New("file", readPassphrase)
+		err = store.Set(key)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Authentication key stored in %s.\n", store.Name())
+	return nil
+}
+
+// runAuthLogout removes the stored authentication key.
+func runAuthLogout(args []string) error {
+	if len(args) != 0 {
+		return errors.New(authUsage)
+	}
+
+	var errs []error
+	for _, store := range credentials.Stores(nil) {
+		if err := store.Delete(); err != nil {
+			errs = append(errs, fmt.Errorf("error removing key from %s: %w", store.Name(), err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	fmt.Println("Stored authentication key removed.")
+	return nil
+}
+
+// runAuthStatus shows which authentication key is used and whether it is
+// valid.
+func runAuthStatus(cfg config.Config, args []string) error {
+	if len(args) != 0 {
+		return errors.New(authUsage)
+	}
+
+	key, source, err := parseAuthKey(cfg)
+	if err != nil {
+		return err
+	}
+	if key == "" {
+		fmt.Println("Not logged in, use `deepl-tui auth login` to store an authentication key.")
+		return nil
+	}
+
+	auth := authInfo{key: key, source: source, serverURL: cfg.ServerURL}
+	keyType := "Pro"
+	if isFreeKey(key) {
+		keyType = "Free"
+	}
+	fmt.Printf("Authentication key %s from %s\n", credentials.Mask(key), source)
+	fmt.Printf("Key type: DeepL API %s\n", keyType)
+	fmt.Printf("Endpoint: %s\n", auth.endpoint())
+	if warning := auth.warning(); warning != "" {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+
+	if err := verifyAuthKey(cfg, key); err != nil {
+		return err
+	}
+	fmt.Println("The key is valid.")
+	return nil
+}
+
+// verifyAuthKey checks the authentication key by requesting the usage.
+func verifyAuthKey(cfg config.Config, key string) error {
+	client, err := newAPIClient(cfg)
+	if err != nil {
+		return err
+	}
+	translator, err := newTranslator(key, cfg.ServerURL, client)
+	if err != nil {
+		return err
+	}
+	if _, err := translator.GetUsage(); err != nil {
+		return fmt.Errorf("error verifying authentication key: %w", err)
+	}
+	return nil
+}
+
+// stdin reads secrets if the standard input is not a terminal.
+var stdin = bufio.NewReader(os.Stdin)
+
+// readSecret reads a secret from the terminal without echoing it. If the
+// standard input is not a terminal, a line is read from it.
+func readSecret(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, err := stdin.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", err
+		}
+		return strings.TrimSpace(line), nil
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	secret, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(secret)), nil
+}
+
+// readPassphrase asks for the passphrase of the encrypted credentials file.
+func readPassphrase(confirm bool) (string, error) {
+	passphrase, err := readSecret("Passphrase of the credentials file: ")
+	if err != nil || !confirm {
+		return passphrase, err
+	}
+
+	repeated, err := readSecret("Repeat the passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase != repeated {
+		return "", errors.New("passphrases do not match")
+	}
+	return passphrase, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/cluttrdev/deepl-go/deepl"
+
+	"github.com/DeepLcom/deepl-tui/internal/batch"
+	"github.com/DeepLcom/deepl-tui/internal/handlers"
+	"github.com/DeepLcom/deepl-tui/internal/markdown"
+)
+
+// maxChunkSize is the maximum size of text sent in a single translate request.
+const maxChunkSize = 64 * 1024
+
+// runBatch translates a set of files into one or more target languages.
+func runBatch(translator *deepl.Translator, args []string) error {
+	const usage = "usage: deepl-tui batch --to LANGS [--out-dir DIR] FILES..."
+
+	flags := flag.NewFlagSet("batch", flag.ContinueOnError)
+	to := flags.String("to", "", "comma-separated list of target languages.")
+	from := flags.String("from", "", "the source language, detected if not set.")
+	formality := flags.String("formality", "", "the formality of the translations.")
+	outDir := flags.String("out-dir", ".", "the output directory.")
+	stateFile := flags.String("state", "", "the state file (default `<out-dir>/.deepl-batch.json`).")
+	concurrency := flags.Int("concurrency", 4, "the number of files translated at the same time.")
+	rate := flags.Float64("rate", 5, "the maximum number of translate requests per second.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *to == "" || flags.NArg() == 0 {
+		return errors.New(usage)
+	}
+
+	var langs []string
+	for _, lang := range strings.Split(*to, ",") {
+		if lang = strings.TrimSpace(lang); lang != "" {
+			langs = append(langs, strings.ToUpper(lang))
+		}
+	}
+	if len(langs) == 0 {
+		return errors.New(usage)
+	}
+
+	files, err := batch.CollectFiles(flags.Args(), []string{".txt", ".md", ".markdown"})
+	if err != nil {
+		return err
+	}
+
+	if *stateFile == "" {
+		*stateFile = filepath.Join(*outDir, ".deepl-batch.json")
+	}
+
+	var opts []deepl.TranslateOption
+	if *from != "" {
+		opts = append(opts, deepl.WithSourceLang(*from))
+	}
+	if *formality != "" {
+		opts = append(opts, deepl.WithFormality(*formality))
+	}
+
+	limiter := batch.NewLimiter(*rate)
+	defer limiter.Stop()
+
+	translate := func(ctx context.Context, path string, text string, targetLang string) (string, error) {
+		// files may need many requests, each of them waits for the limiter
+		textTranslator := func(opts ...deepl.TranslateOption) func([]string) ([]string, error) {
+			translateBatch := handlers.TextTranslator(translator, targetLang, opts...)
+			return func(texts []string) ([]string, error) {
+				return handlers.TranslateInBatches(texts, func(texts []string) ([]string, error) {
+					if err := limiter.Wait(ctx); err != nil {
+						return nil, err
+					}
+					return translateBatch(texts)
+				})
+			}
+		}
+
+		if isMarkdownFile(path) {
+			return markdown.Translate(text, textTranslator(append(opts, deepl.WithTagHandling("xml"))...))
+		}
+
+		// one chunk per request to stay below the request size limit
+		translateText := textTranslator(append(opts, deepl.WithPreserveFormatting(true))...)
+		var b strings.Builder
+		for _, chunk := range batch.SplitText(text, maxChunkSize) {
+			translations, err := translateText([]string{chunk})
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(strings.Join(translations, ""))
+		}
+		return b.String(), nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	summary, err := batch.Run(ctx, files, translate, batch.Options{
+		TargetLangs: langs,
+		OutDir:      *outDir,
+		StatePath:   *stateFile,
+		Concurrency: *concurrency,
+		Fingerprint: strings.Join([]string{*from, *formality}, ":"),
+		Progress:    os.Stderr,
+	})
+	fmt.Fprintf(os.Stderr, "%d translated, %d up to date, %d failed\n",
+		summary.Translated, summary.Skipped, summary.Failed)
+	if errors.Is(err, context.Canceled) {
+		return errors.New("interrupted, run the same command again to resume")
+	}
+	return err
+}
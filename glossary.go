@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cluttrdev/deepl-go/deepl"
+
+	"github.com/DeepLcom/deepl-tui/internal/handlers"
+)
+
+func runGlossaryCommand(translator *deepl.Translator, args []string) error {
+	if len(args) < 1 {
+		return errors.New("usage: deepl-tui glossary sync [--yes] DIR")
+	}
+
+	switch args[0] {
+	case "sync":
+		return runGlossarySync(translator, args[1:])
+	default:
+		return fmt.Errorf("unknown glossary command: %s", args[0])
+	}
+}
+
+// runGlossarySync makes the remote glossaries match the glossary files in a
+// local directory.
+func runGlossarySync(translator *deepl.Translator, args []string) error {
+	flags := flag.NewFlagSet("glossary sync", flag.ContinueOnError)
+	yes := flags.Bool("yes", false, "apply the sync plan without asking for confirmation.")
+	dryRun := flags.Bool("dry-run", false, "only show the sync plan.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: deepl-tui glossary sync [--yes] [--dry-run] DIR")
+	}
+
+	files, err := handlers.ReadGlossaryDir(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var glossaries handlers.GlossariesHandler
+	steps, err := glossaries.PlanSync(translator, files)
+	if err != nil {
+		return err
+	}
+
+	if len(steps) == 0 {
+		fmt.Println("Glossaries are up to date.")
+		return nil
+	}
+
+	fmt.Println("Sync plan:")
+	for _, step := range steps {
+		fmt.Printf("  %s\n", step)
+	}
+
+	if *dryRun {
+		return nil
+	}
+	if !*yes && !confirm("Apply these changes?") {
+		fmt.Println("Aborted.")
+		return nil
+	}
+
+	if err := glossaries.ApplySync(translator, steps); err != nil {
+		return err
+	}
+	fmt.Printf("Applied %d changes.\n", len(steps))
+	return nil
+}
+
+// confirm asks the user a yes/no question on stdin.
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
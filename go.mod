@@ -8,6 +8,9 @@ require (
 	github.com/gdamore/tcell/v2 v2.7.4
 	github.com/mattn/go-shellwords v1.0.12
 	github.com/rivo/tview v0.0.0-20240524063012-037df494fb76
+	golang.org/x/crypto v0.24.0
+	golang.org/x/term v0.21.0
+	golang.org/x/text v0.16.0
 )
 
 require (
@@ -16,6 +19,4 @@ require (
 	github.com/mattn/go-runewidth v0.0.15 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
 	golang.org/x/sys v0.21.0 // indirect
-	golang.org/x/term v0.21.0 // indirect
-	golang.org/x/text v0.16.0 // indirect
 )
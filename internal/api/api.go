@@ -0,0 +1,331 @@
+// Package api provides the HTTP client used for all DeepL API requests.
+//
+// The client retries transient errors (too many requests, server errors and
+// network errors) with exponential backoff and jitter, honouring the
+// Retry-After header, and turns error responses into an *Error with a
+// human-readable message.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cluttrdev/deepl-go/deepl"
+)
+
+// ErrorKind classifies API errors.
+type ErrorKind int
+
+const (
+	// ErrRequest is an error response not covered by another kind, e.g. a
+	// bad request.
+	ErrRequest ErrorKind = iota
+	// ErrAuth is an authentication or authorization error.
+	ErrAuth
+	// ErrQuota means the character limit of the account has been reached.
+	ErrQuota
+	// ErrRateLimit means too many requests were sent.
+	ErrRateLimit
+	// ErrServer is an internal server error or an unavailable service.
+	ErrServer
+	// ErrNetwork is an error sending the request or reading the response.
+	ErrNetwork
+)
+
+// Error is an error returned by the API or the network.
+type Error struct {
+	Kind       ErrorKind
+	StatusCode int
+	// Message is the error message sent by the API, if any.
+	Message string
+	// Err is the underlying network error.
+	Err error
+}
+
+func (e *Error) Error() string {
+	switch e.Kind {
+	case ErrAuth:
+		return fmt.Sprintf("Authentication failed (%d), please check your authentication key", e.StatusCode)
+	case ErrQuota:
+		return "Quota exceeded, the character limit of your DeepL account has been reached"
+	case ErrRateLimit:
+		return "Too many requests, please try again later"
+	case ErrServer:
+		return fmt.Sprintf("DeepL is currently unavailable (%d), please try again later", e.StatusCode)
+	case ErrNetwork:
+		return fmt.Sprintf("Network error: %v", e.Err)
+	}
+	if e.Message != "" {
+		return fmt.Sprintf("%d - %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+	}
+	return fmt.Sprintf("%d - %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Temporary reports whether the request may succeed when retried.
+func (e *Error) Temporary() bool {
+	return e.Kind == ErrRateLimit || e.Kind == ErrServer || e.Kind == ErrNetwork
+}
+
+// RetryFunc is called before a request is retried.
+type RetryFunc func(err *Error, attempt int, delay time.Duration)
+
+// Client is an HTTP client that retries transient errors.
+type Client struct {
+	client deepl.HTTPClient
+
+	userAgent string
+
+	logger      *slog.Logger
+	logPayloads bool
+
+	maxRetries   int
+	initialDelay time.Duration
+	maxDelay     time.Duration
+
+	mu      sync.Mutex
+	onRetry RetryFunc
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the underlying HTTP client.
+func WithHTTPClient(hc deepl.HTTPClient) Option {
+	return func(c *Client) {
+		c.client = hc
+	}
+}
+
+// WithUserAgent sets the User-Agent header of all requests.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithLogger logs every request to the given logger. If `payloads` is true,
+// the request and response bodies, i.e. the texts and translations, are
+// logged at debug level.
+func WithLogger(logger *slog.Logger, payloads bool) Option {
+	return func(c *Client) {
+		c.logger = logger
+		c.logPayloads = payloads
+	}
+}
+
+// WithRetries sets the maximum number of retries and the initial and
+// maximum delay between retries.
+func WithRetries(max int, initialDelay time.Duration, maxDelay time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = max
+		c.initialDelay = initialDelay
+		c.maxDelay = maxDelay
+	}
+}
+
+// NewClient creates a new client.
+func NewClient(opts ...Option) *Client {
+	c := &Client{
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		maxRetries:   4,
+		initialDelay: 500 * time.Millisecond,
+		maxDelay:     30 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// SetRetryFunc sets a function that is called before a request is retried,
+// e.g. to tell the user about the delay.
+func (c *Client) SetRetryFunc(f RetryFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onRetry = f
+}
+
+// Do sends a request, retrying transient errors. Responses with an error
+// status are returned as *Error.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	res, retries, err := c.do(req)
+	if c.logger != nil {
+		res = c.logRequest(req, res, retries, err, time.Since(start))
+	}
+	return res, err
+}
+
+// do sends a request, retrying transient errors, and returns the number of
+// retries.
+func (c *Client) do(req *http.Request) (*http.Response, int, error) {
+	for attempt := 0; ; attempt++ {
+		// track whether the request reached the server, a request that
+		// fails before it has been written can always be retried
+		var wrote atomic.Bool
+		trace := &httptrace.ClientTrace{
+			WroteRequest: func(httptrace.WroteRequestInfo) {
+				wrote.Store(true)
+			},
+		}
+		r := req.Clone(httptrace.WithClientTrace(req.Context(), trace))
+		if attempt > 0 && req.Body != nil {
+			if req.GetBody == nil {
+				return nil, attempt, errors.New("cannot retry request without GetBody")
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, attempt, err
+			}
+			r.Body = body
+		}
+		if c.userAgent != "" {
+			r.Header.Set("User-Agent", c.userAgent)
+		}
+
+		res, err := c.client.Do(r)
+		apiErr, retryAfter := classify(res, err)
+		if apiErr == nil {
+			return res, attempt, nil
+		}
+		if errors.Is(err, context.Canceled) || !retryable(req.Method, apiErr, wrote.Load()) || attempt >= c.maxRetries {
+			return nil, attempt, apiErr
+		}
+
+		delay := c.backoff(attempt)
+		if retryAfter > 0 {
+			delay = min(retryAfter, c.maxDelay)
+		}
+
+		if c.logger != nil {
+			c.logger.LogAttrs(req.Context(), slog.LevelWarn, "api request retry",
+				slog.String("method", req.Method),
+				slog.String("endpoint", req.URL.Path),
+				slog.Int("attempt", attempt+1),
+				slog.Int64("delay_ms", delay.Milliseconds()),
+				slog.String("error", apiErr.Error()),
+			)
+		}
+
+		c.mu.Lock()
+		onRetry := c.onRetry
+		c.mu.Unlock()
+		if onRetry != nil {
+			onRetry(apiErr, attempt+1, delay)
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return nil, attempt, apiErr
+		}
+	}
+}
+
+// retryable reports whether a failed request can be sent again. Requests
+// with idempotent methods are retried on every temporary error. Other
+// requests, e.g. translations and glossary creation, may already have been
+// processed, so that a retry would bill the text twice or create a duplicate
+// glossary. They are only retried if the server rejected them with 429 or 503,
+// or if the request was not written at all.
+func retryable(method string, err *Error, wrote bool) bool {
+	if !err.Temporary() {
+		return false
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	switch {
+	case err.Kind == ErrRateLimit:
+		return true
+	case err.Kind == ErrServer:
+		return err.StatusCode == http.StatusServiceUnavailable
+	case err.Kind == ErrNetwork:
+		return !wrote
+	}
+	return false
+}
+
+// backoff returns the delay before the given retry attempt, doubling the
+// delay for each attempt and picking a random delay between half and the
+// full value.
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.initialDelay << attempt
+	if delay <= 0 || delay > c.maxDelay {
+		delay = c.maxDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// classify returns the error for a response or network error and the delay
+// requested by the Retry-After header. It closes the body of error
+// responses.
+func classify(res *http.Response, err error) (*Error, time.Duration) {
+	if err != nil {
+		return &Error{Kind: ErrNetwork, Err: err}, 0
+	}
+	if res.StatusCode < 400 {
+		return nil, 0
+	}
+	defer res.Body.Close()
+
+	e := &Error{StatusCode: res.StatusCode}
+	switch {
+	case res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden:
+		e.Kind = ErrAuth
+	case res.StatusCode == 456:
+		e.Kind = ErrQuota
+	case res.StatusCode == http.StatusTooManyRequests:
+		e.Kind = ErrRateLimit
+	case res.StatusCode >= 500:
+		e.Kind = ErrServer
+	}
+
+	var body struct {
+		Message string `json:"message"`
+		Detail  string `json:"detail"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(res.Body, 64*1024))
+	if json.Unmarshal(data, &body) == nil {
+		e.Message = body.Message
+		if body.Detail != "" {
+			e.Message += " (" + body.Detail + ")"
+		}
+	}
+
+	return e, retryAfter(res.Header.Get("Retry-After"))
+}
+
+// retryAfter parses a Retry-After header given in seconds or as HTTP date.
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
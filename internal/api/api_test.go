@@ -0,0 +1,203 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		method string
+		err    *Error
+		wrote  bool
+		want   bool
+	}{
+		{http.MethodGet, &Error{Kind: ErrServer, StatusCode: 500}, true, true},
+		{http.MethodDelete, &Error{Kind: ErrNetwork}, true, true},
+		{http.MethodGet, &Error{Kind: ErrAuth, StatusCode: 403}, true, false},
+		{http.MethodGet, &Error{Kind: ErrRequest, StatusCode: 400}, true, false},
+		{http.MethodPost, &Error{Kind: ErrRateLimit, StatusCode: 429}, true, true},
+		{http.MethodPost, &Error{Kind: ErrServer, StatusCode: 503}, true, true},
+		{http.MethodPost, &Error{Kind: ErrServer, StatusCode: 500}, true, false},
+		{http.MethodPost, &Error{Kind: ErrServer, StatusCode: 504}, true, false},
+		{http.MethodPost, &Error{Kind: ErrNetwork}, false, true},
+		{http.MethodPost, &Error{Kind: ErrNetwork}, true, false},
+		{http.MethodPost, &Error{Kind: ErrQuota, StatusCode: 456}, true, false},
+	}
+
+	for _, tt := range tests {
+		if got := retryable(tt.method, tt.err, tt.wrote); got != tt.want {
+			t.Errorf("retryable(%s, %v, %v) = %v, want %v", tt.method, tt.err, tt.wrote, got, tt.want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	c := NewClient(WithRetries(10, 100*time.Millisecond, time.Second))
+
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{0, 100 * time.Millisecond},
+		{1, 200 * time.Millisecond},
+		{3, 800 * time.Millisecond},
+		{4, time.Second},
+		{70, time.Second},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			if d := c.backoff(tt.attempt); d < tt.max/2 || d > tt.max {
+				t.Errorf("backoff(%d) = %v, want between %v and %v", tt.attempt, d, tt.max/2, tt.max)
+				break
+			}
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{"", 0, 0},
+		{"0", 0, 0},
+		{"3", 3 * time.Second, 3 * time.Second},
+		{"-1", 0, 0},
+		{"soon", 0, 0},
+		{time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), 58 * time.Second, time.Minute},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0, 0},
+	}
+
+	for _, tt := range tests {
+		if got := retryAfter(tt.value); got < tt.min || got > tt.max {
+			t.Errorf("retryAfter(%q) = %v, want between %v and %v", tt.value, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		status     int
+		body       string
+		retryAfter string
+		kind       ErrorKind
+		message    string
+		delay      time.Duration
+	}{
+		{400, `{"message": "Bad request", "detail": "Value for 'target_lang' not supported."}`, "", ErrRequest, "Bad request (Value for 'target_lang' not supported.)", 0},
+		{403, `{"message": "Wrong key"}`, "", ErrAuth, "Wrong key", 0},
+		{401, ``, "", ErrAuth, "", 0},
+		{456, `{"message": "Quota exceeded"}`, "", ErrQuota, "Quota exceeded", 0},
+		{429, `not json`, "2", ErrRateLimit, "", 2 * time.Second},
+		{500, ``, "", ErrServer, "", 0},
+		{503, ``, "1", ErrServer, "", time.Second},
+	}
+
+	for _, tt := range tests {
+		res := &http.Response{
+			StatusCode: tt.status,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader(tt.body)),
+		}
+		if tt.retryAfter != "" {
+			res.Header.Set("Retry-After", tt.retryAfter)
+		}
+
+		err, delay := classify(res, nil)
+		if err == nil {
+			t.Errorf("classify(%d) = nil, want error", tt.status)
+			continue
+		}
+		if err.Kind != tt.kind || err.StatusCode != tt.status || err.Message != tt.message || delay != tt.delay {
+			t.Errorf("classify(%d) = %+v, %v, want kind %v, message %q, delay %v", tt.status, err, delay, tt.kind, tt.message, tt.delay)
+		}
+	}
+
+	if err, _ := classify(&http.Response{StatusCode: 200}, nil); err != nil {
+		t.Errorf("classify(200) = %v, want nil", err)
+	}
+
+	netErr := errors.New("connection refused")
+	err, _ := classify(nil, netErr)
+	if err == nil || err.Kind != ErrNetwork || !errors.Is(err, netErr) || !err.Temporary() {
+		t.Errorf("classify(network error) = %+v, want temporary network error", err)
+	}
+}
+
+func TestClientDo(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		statuses []int
+		want     int
+		requests int
+		retries  int
+	}{
+		{"success", http.MethodPost, []int{200}, 200, 1, 0},
+		{"post retried on 503", http.MethodPost, []int{503, 429, 200}, 200, 3, 2},
+		{"post not retried on 500", http.MethodPost, []int{500, 200}, 500, 1, 0},
+		{"get retried on 500", http.MethodGet, []int{500, 200}, 200, 2, 1},
+		{"no retry on bad request", http.MethodGet, []int{400, 200}, 400, 1, 0},
+		{"retries exhausted", http.MethodGet, []int{502, 502, 502, 502}, 502, 3, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(requests.Add(1)) - 1
+				if body, _ := io.ReadAll(r.Body); r.Method == http.MethodPost && string(body) != "text=hello" {
+					t.Errorf("request %d has body %q", n, body)
+				}
+				if ua := r.Header.Get("User-Agent"); ua != "test-agent" {
+					t.Errorf("User-Agent = %q", ua)
+				}
+				w.WriteHeader(tt.statuses[n])
+			}))
+			defer srv.Close()
+
+			c := NewClient(WithUserAgent("test-agent"), WithRetries(2, time.Millisecond, 10*time.Millisecond))
+			var retries int
+			c.SetRetryFunc(func(err *Error, attempt int, delay time.Duration) {
+				retries = attempt
+			})
+
+			req, err := http.NewRequest(tt.method, srv.URL+"/v2/translate", strings.NewReader("text=hello"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := c.Do(req)
+
+			status := 0
+			var apiErr *Error
+			switch {
+			case err == nil:
+				status = res.StatusCode
+				res.Body.Close()
+			case errors.As(err, &apiErr):
+				status = apiErr.StatusCode
+			default:
+				t.Fatalf("Do: %v", err)
+			}
+
+			if status != tt.want {
+				t.Errorf("status = %d, want %d", status, tt.want)
+			}
+			if n := int(requests.Load()); n != tt.requests {
+				t.Errorf("sent %d requests, want %d", n, tt.requests)
+			}
+			if retries != tt.retries {
+				t.Errorf("retried %d times, want %d", retries, tt.retries)
+			}
+		})
+	}
+}
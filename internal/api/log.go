@@ -0,0 +1,131 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/DeepLcom/deepl-tui/internal/credentials"
+)
+
+// maxLoggedPayload is the maximum number of bytes of a logged request or
+// response body.
+const maxLoggedPayload = 4096
+
+// logRequest logs a finished request. The authentication key is redacted.
+// If payloads are logged, the response body is read and replaced, so the
+// possibly replaced response is returned.
+func (c *Client) logRequest(req *http.Request, res *http.Response, retries int, err error, latency time.Duration) *http.Response {
+	ctx := req.Context()
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("endpoint", req.URL.Path),
+		slog.Int64("latency_ms", latency.Milliseconds()),
+		slog.Int("retries", retries),
+		slog.String("auth_key", redactAuthorization(req.Header.Get("Authorization"))),
+	}
+
+	var apiErr *Error
+	switch {
+	case res != nil:
+		attrs = append(attrs, slog.Int("status", res.StatusCode))
+	case errors.As(err, &apiErr) && apiErr.StatusCode != 0:
+		attrs = append(attrs, slog.Int("status", apiErr.StatusCode))
+	}
+
+	body := requestBody(req)
+	if n := billedCharacters(req, body); n > 0 {
+		attrs = append(attrs, slog.Int("billed_characters", n))
+	}
+
+	level := slog.LevelInfo
+	if err != nil {
+		level = slog.LevelError
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	c.logger.LogAttrs(ctx, level, "api request", attrs...)
+
+	if !c.logPayloads || !c.logger.Enabled(ctx, slog.LevelDebug) {
+		return res
+	}
+
+	payload := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("endpoint", req.URL.Path),
+		slog.String("request", truncate(body)),
+	}
+	if res != nil && res.Body != nil {
+		data, readErr := io.ReadAll(res.Body)
+		res.Body.Close()
+		res.Body = io.NopCloser(bytes.NewReader(data))
+		if readErr == nil {
+			payload = append(payload, slog.String("response", truncate(data)))
+		}
+	}
+	c.logger.LogAttrs(ctx, slog.LevelDebug, "api payload", payload...)
+
+	return res
+}
+
+// requestBody returns a copy of the request body.
+func requestBody(req *http.Request) []byte {
+	if req.GetBody == nil {
+		return nil
+	}
+	r, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer r.Close()
+	data, _ := io.ReadAll(r)
+	return data
+}
+
+// billedCharacters returns the number of characters of the texts of a
+// translate request, which are billed.
+func billedCharacters(req *http.Request, body []byte) int {
+	if !strings.HasSuffix(req.URL.Path, "/translate") {
+		return 0
+	}
+
+	var data struct {
+		Text []string `json:"text"`
+	}
+	if json.Unmarshal(body, &data) != nil {
+		return 0
+	}
+
+	n := 0
+	for _, text := range data.Text {
+		n += utf8.RuneCountInString(text)
+	}
+	return n
+}
+
+// redactAuthorization returns the masked authentication key of an
+// Authorization header.
+func redactAuthorization(value string) string {
+	_, key, ok := strings.Cut(value, " ")
+	if !ok {
+		return ""
+	}
+	return credentials.Mask(key)
+}
+
+func truncate(data []byte) string {
+	if len(data) <= maxLoggedPayload {
+		return string(data)
+	}
+	n := maxLoggedPayload
+	for n > 0 && !utf8.RuneStart(data[n]) {
+		n--
+	}
+	return string(data[:n]) + "..."
+}
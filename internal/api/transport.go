@@ -0,0 +1,47 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// NewTransport creates an HTTP transport using the given proxy URL and
+// additional CA certificates.
+//
+// If `proxy` is empty, the proxy is taken from the HTTPS_PROXY, HTTP_PROXY
+// and NO_PROXY environment variables. If `caFile` is not empty, the PEM
+// encoded certificates in the file are trusted in addition to the system
+// certificates, e.g. for a TLS-intercepting proxy.
+func NewTransport(proxy string, caFile string) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	if proxy != "" {
+		u, err := url.Parse(proxy)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL: %s", proxy)
+		}
+		t.Proxy = http.ProxyURL(u)
+	}
+
+	if caFile != "" {
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, errors.New("no certificates found in CA file " + caFile)
+		}
+		t.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	return t, nil
+}
@@ -0,0 +1,387 @@
+// Package batch translates many files concurrently, skipping files whose
+// translation is up to date, and keeps track of finished translations in a
+// state file so that an interrupted run can be resumed.
+package batch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// TranslateFunc translates the content of a file into the target language.
+// The path is passed so that the translation can depend on the file type.
+// The context is cancelled when the batch run is interrupted.
+type TranslateFunc func(ctx context.Context, path string, text string, targetLang string) (string, error)
+
+// Options configures a batch run.
+type Options struct {
+	// TargetLangs are the languages to translate into.
+	TargetLangs []string
+	// OutDir is the output directory. Translations are written to
+	// `<OutDir>/<lang>/<input path>`.
+	OutDir string
+	// StatePath is the path of the state file.
+	StatePath string
+	// Concurrency is the number of files translated at the same time.
+	Concurrency int
+	// Fingerprint identifies the translation settings. Translations done
+	// with different settings are not up to date.
+	Fingerprint string
+
+	// Progress receives a message for every finished or skipped file.
+	Progress io.Writer
+}
+
+// Summary reports the result of a batch run.
+type Summary struct {
+	Translated int
+	Skipped    int
+	Failed     int
+}
+
+type job struct {
+	input      string
+	output     string
+	targetLang string
+	hash       string
+}
+
+// Run translates the given files into all target languages.
+// It stops starting new translations when the context is cancelled.
+func Run(ctx context.Context, files []string, translate TranslateFunc, opts Options) (Summary, error) {
+	var summary Summary
+
+	state, err := loadState(opts.StatePath)
+	if err != nil {
+		return summary, err
+	}
+
+	if err := checkOutputs(files, opts); err != nil {
+		return summary, err
+	}
+
+	var jobs []job
+	for _, input := range files {
+		data, err := os.ReadFile(input)
+		if err != nil {
+			return summary, err
+		}
+		hash := contentHash(data, opts.Fingerprint)
+
+		for _, lang := range opts.TargetLangs {
+			j := job{
+				input:      input,
+				output:     OutputPath(opts.OutDir, lang, input),
+				targetLang: lang,
+				hash:       hash,
+			}
+			if state.upToDate(j) {
+				summary.Skipped++
+				continue
+			}
+			jobs = append(jobs, j)
+		}
+	}
+
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+
+	var (
+		mu       sync.Mutex
+		done     int
+		errs     []error
+		wg       sync.WaitGroup
+		jobQueue = make(chan job)
+	)
+	report := func(j job, err error, elapsed time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		done++
+		status := fmt.Sprintf("done (%s)", elapsed.Round(time.Millisecond))
+		if err != nil {
+			summary.Failed++
+			errs = append(errs, fmt.Errorf("%s (%s): %w", j.input, j.targetLang, err))
+			status = fmt.Sprintf("failed: %v", err)
+		} else {
+			summary.Translated++
+			state.set(j)
+			if serr := state.save(opts.StatePath); serr != nil {
+				errs = append(errs, serr)
+			}
+		}
+		if opts.Progress != nil {
+			fmt.Fprintf(opts.Progress, "[%d/%d] %s -> %s %s\n", done, len(jobs), j.input, j.output, status)
+		}
+	}
+
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobQueue {
+				start := time.Now()
+				hash, err := translateFile(ctx, j, translate, opts.Fingerprint)
+				// record the hash of the content that was translated, the
+				// file may have changed since the jobs were planned
+				j.hash = hash
+				report(j, err, time.Since(start))
+			}
+		}()
+	}
+
+queue:
+	for _, j := range jobs {
+		select {
+		case jobQueue <- j:
+		case <-ctx.Done():
+			break queue
+		}
+	}
+	close(jobQueue)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return summary, errors.Join(errs...)
+}
+
+// translateFile translates the input file of the job and returns the hash of
+// the translated content.
+func translateFile(ctx context.Context, j job, translate TranslateFunc, fingerprint string) (string, error) {
+	data, err := os.ReadFile(j.input)
+	if err != nil {
+		return "", err
+	}
+
+	text, err := translate(ctx, j.input, string(data), j.targetLang)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(j.output), 0o755); err != nil {
+		return "", err
+	}
+	return contentHash(data, fingerprint), os.WriteFile(j.output, []byte(text), 0o644)
+}
+
+// contentHash returns the hash identifying the content of a file translated
+// with the settings identified by the fingerprint.
+func contentHash(data []byte, fingerprint string) string {
+	sum := sha256.Sum256(append(data, fingerprint...))
+	return hex.EncodeToString(sum[:])
+}
+
+// Limiter limits the rate of translate requests. A nil Limiter does not
+// limit the rate.
+type Limiter struct {
+	ticker *time.Ticker
+}
+
+// NewLimiter returns a limiter allowing the given number of requests per
+// second, or nil if the rate is not positive.
+func NewLimiter(rate float64) *Limiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &Limiter{ticker: time.NewTicker(time.Duration(float64(time.Second) / rate))}
+}
+
+// Wait blocks until the next request may be sent or the context is
+// cancelled.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+	select {
+	case <-l.ticker.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stop releases the resources of the limiter.
+func (l *Limiter) Stop() {
+	if l != nil {
+		l.ticker.Stop()
+	}
+}
+
+// OutputPath returns the path of the translation of the given input file.
+// Absolute paths and paths outside the working directory are reduced to the
+// file name, so they may collide; see [Run].
+func OutputPath(outDir string, lang string, input string) string {
+	rel := filepath.Clean(input)
+	if filepath.IsAbs(rel) || strings.HasPrefix(rel, "..") {
+		rel = filepath.Base(rel)
+	}
+	return filepath.Join(outDir, strings.ToLower(lang), rel)
+}
+
+// checkOutputs returns an error if several input files would be written to
+// the same output file, e.g. `../a/README.md` and `../b/README.md`, which are
+// both written as `README.md`.
+func checkOutputs(files []string, opts Options) error {
+	inputs := make(map[string]string)
+	for _, input := range files {
+		for _, lang := range opts.TargetLangs {
+			output := OutputPath(opts.OutDir, lang, input)
+			if other, ok := inputs[output]; ok && filepath.Clean(other) != filepath.Clean(input) {
+				return fmt.Errorf("%s and %s would both be written to %s, translate them separately", other, input, output)
+			}
+			inputs[output] = input
+		}
+	}
+	return nil
+}
+
+// CollectFiles returns the given files and all files with one of the given
+// extensions in the given directories.
+func CollectFiles(paths []string, exts []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			ext := strings.ToLower(filepath.Ext(p))
+			for _, e := range exts {
+				if ext == e {
+					files = append(files, p)
+					break
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// state records the finished translations.
+type state struct {
+	Files map[string]stateEntry `json:"files"`
+}
+
+type stateEntry struct {
+	Hash   string `json:"hash"`
+	Output string `json:"output"`
+}
+
+func loadState(path string) (*state, error) {
+	s := &state{Files: make(map[string]stateEntry)}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("error reading state file %s: %w", path, err)
+	}
+	if s.Files == nil {
+		s.Files = make(map[string]stateEntry)
+	}
+	return s, nil
+}
+
+func stateKey(j job) string {
+	return strings.ToLower(j.targetLang) + ":" + filepath.ToSlash(j.input)
+}
+
+// upToDate reports whether the output of the job exists and was translated
+// from the current input with the current settings.
+func (s *state) upToDate(j job) bool {
+	if _, err := os.Stat(j.output); err != nil {
+		return false
+	}
+	e, ok := s.Files[stateKey(j)]
+	return ok && e.Hash == j.hash && e.Output == j.output
+}
+
+func (s *state) set(j job) {
+	s.Files[stateKey(j)] = stateEntry{Hash: j.hash, Output: j.output}
+}
+
+// save writes the state file atomically.
+func (s *state) save(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// SplitText splits text into chunks of at most `max` bytes at paragraph
+// boundaries, so that large files can be translated in several requests.
+// Paragraphs longer than `max` are split at line boundaries, or at `max`
+// bytes if a single line is too long. Joining the chunks yields the text.
+func SplitText(text string, max int) []string {
+	if len(text) <= max {
+		return []string{text}
+	}
+
+	var chunks []string
+	for len(text) > max {
+		cut := strings.LastIndex(text[:max], "\n\n")
+		if cut > 0 {
+			cut += 2
+		} else if cut = strings.LastIndex(text[:max], "\n"); cut > 0 {
+			cut++
+		} else {
+			cut = max
+			for cut > 0 && !utf8.RuneStart(text[cut]) {
+				cut--
+			}
+		}
+		chunks = append(chunks, text[:cut])
+		text = text[cut:]
+	}
+	if text != "" {
+		chunks = append(chunks, text)
+	}
+	return chunks
+}
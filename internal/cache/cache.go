@@ -0,0 +1,118 @@
+// Package cache persists remote data, i.e. the supported languages and the
+// glossaries, so that the application can start without network access.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cluttrdev/deepl-go/deepl"
+
+	"github.com/DeepLcom/deepl-tui/internal/handlers"
+)
+
+// version is the version of the cache file format. Files with a different
+// version are ignored.
+const version = 1
+
+// Data is the cached remote data.
+type Data struct {
+	Version    int        `json:"version"`
+	Languages  Languages  `json:"languages"`
+	Glossaries Glossaries `json:"glossaries"`
+}
+
+// Languages holds the supported source and target languages.
+type Languages struct {
+	Source  []deepl.Language `json:"source"`
+	Target  []deepl.Language `json:"target"`
+	Updated time.Time        `json:"updated"`
+}
+
+// Glossaries holds the glossaries, their entries and the supported glossary
+// language pairs.
+type Glossaries struct {
+	handlers.GlossariesState
+	// Multilingual reports whether the v3 glossary API was available.
+	Multilingual bool      `json:"multilingual"`
+	Updated      time.Time `json:"updated"`
+}
+
+// DefaultPath returns the path of the cache file for the given
+// authentication key and server URL in the user's cache directory. Every key
+// and server has its own file, since glossaries belong to an account.
+func DefaultPath(authKey string, serverURL string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(authKey + "\n" + serverURL))
+	return filepath.Join(dir, "deepl-tui", hex.EncodeToString(sum[:8])+".json")
+}
+
+// Load reads the cache file at the given path.
+// A missing file or a file of a different version yields empty data.
+func Load(path string) (Data, error) {
+	var d Data
+	if path == "" {
+		return d, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return d, nil
+	} else if err != nil {
+		return d, err
+	}
+
+	if err := json.Unmarshal(data, &d); err != nil {
+		return Data{}, fmt.Errorf("error reading cache file %s: %w", path, err)
+	}
+	if d.Version != version {
+		return Data{}, nil
+	}
+	return d, nil
+}
+
+// Save writes the cache file atomically.
+func Save(path string, d Data) error {
+	if path == "" {
+		return nil
+	}
+
+	d.Version = version
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Age returns a short description of how long ago `t` was, e.g. `5 min ago`.
+func Age(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%d min ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%d h ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%d days ago", int(d.Hours()/24))
+	}
+}
@@ -0,0 +1,158 @@
+// Package clipboard provides access to the clipboard using different
+// backends: the system clipboard, which requires a clipboard utility like
+// xclip, xsel or wl-clipboard, and OSC 52 terminal escape sequences, which
+// also work in remote sessions.
+package clipboard
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/atotto/clipboard"
+)
+
+// ErrUnsupported is returned by backends that cannot perform an operation.
+var ErrUnsupported = errors.New("operation not supported by clipboard backend")
+
+// Backend copies text to and pastes text from a clipboard.
+type Backend interface {
+	Name() string
+	Copy(text string) error
+	Paste() (string, error)
+}
+
+// New returns the clipboard backend with the given name, one of `auto`,
+// `system` or `osc52`. An empty name is the same as `auto`.
+//
+// The `auto` backend copies using OSC 52 in remote sessions and if no system
+// clipboard utility is available, and uses the system clipboard otherwise.
+func New(name string) (Backend, error) {
+	switch name {
+	case "", "auto":
+		return &autoBackend{
+			system: systemBackend{},
+			osc52:  newOSC52Backend(),
+			remote: os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != "",
+		}, nil
+	case "system":
+		return systemBackend{}, nil
+	case "osc52":
+		return newOSC52Backend(), nil
+	}
+	return nil, fmt.Errorf("unknown clipboard backend: %s", name)
+}
+
+// systemBackend uses the system clipboard.
+type systemBackend struct{}
+
+func (systemBackend) Name() string {
+	return "system"
+}
+
+func (systemBackend) Copy(text string) error {
+	if clipboard.Unsupported {
+		return errors.New("no clipboard utility found, install xclip, xsel or wl-clipboard")
+	}
+	return clipboard.WriteAll(text)
+}
+
+func (systemBackend) Paste() (string, error) {
+	if clipboard.Unsupported {
+		return "", errors.New("no clipboard utility found, install xclip, xsel or wl-clipboard")
+	}
+	return clipboard.ReadAll()
+}
+
+// osc52Backend copies text by sending an OSC 52 escape sequence to the
+// terminal. Since most terminals do not allow reading the clipboard, pasting
+// only returns the text that was last copied.
+type osc52Backend struct {
+	mu   sync.Mutex
+	last string
+}
+
+func newOSC52Backend() *osc52Backend {
+	return &osc52Backend{}
+}
+
+func (*osc52Backend) Name() string {
+	return "osc52"
+}
+
+func (b *osc52Backend) Copy(text string) error {
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("error opening terminal: %w", err)
+	}
+	defer tty.Close()
+
+	if _, err := tty.WriteString(osc52Sequence(text)); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.last = text
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *osc52Backend) Paste() (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.last == "" {
+		return "", ErrUnsupported
+	}
+	return b.last, nil
+}
+
+// osc52Sequence returns the escape sequence to set the clipboard content.
+// Inside tmux and GNU screen, the sequence is wrapped to be passed through to
+// the outer terminal.
+func osc52Sequence(text string) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	switch {
+	case os.Getenv("TMUX") != "":
+		return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		return "\x1bP" + seq + "\x1b\\"
+	}
+	return seq
+}
+
+// autoBackend chooses between the system and OSC 52 backend.
+type autoBackend struct {
+	system systemBackend
+	osc52  *osc52Backend
+	remote bool
+}
+
+func (b *autoBackend) Name() string {
+	if b.remote || clipboard.Unsupported {
+		return "auto (osc52)"
+	}
+	return "auto (system)"
+}
+
+func (b *autoBackend) Copy(text string) error {
+	if !b.remote && !clipboard.Unsupported {
+		if err := b.system.Copy(text); err == nil {
+			return nil
+		}
+	}
+	return b.osc52.Copy(text)
+}
+
+func (b *autoBackend) Paste() (string, error) {
+	text, err := b.system.Paste()
+	if err == nil {
+		return text, nil
+	}
+	if text, err := b.osc52.Paste(); err == nil {
+		return text, nil
+	}
+	return "", err
+}
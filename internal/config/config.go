@@ -0,0 +1,214 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Config holds the user settings read from the configuration file.
+type Config struct {
+	// DefaultGlossaries maps a language pair, e.g. `en-de`, to the name or id
+	// of the glossary that is selected by default for this pair.
+	// A key with only a target language, e.g. `de`, applies to any source
+	// language.
+	DefaultGlossaries map[string]string `json:"default_glossaries"`
+
+	// Clipboard is the clipboard backend, one of `auto`, `system` or `osc52`.
+	Clipboard string `json:"clipboard"`
+
+	// ClipboardWatch configures translating text copied to the clipboard.
+	ClipboardWatch ClipboardWatch `json:"clipboard_watch"`
+
+	// Markdown starts the application in Markdown mode, which keeps code,
+	// URLs and front matter of Markdown input untranslated.
+	Markdown bool `json:"markdown"`
+
+	// Translation configures when the input is translated.
+	Translation Translation `json:"translation"`
+
+	// ConfirmCharacters is the number of characters above which a translation
+	// has to be confirmed before it is requested. Zero disables the
+	// confirmation.
+	ConfirmCharacters int `json:"confirm_characters"`
+
+	// Subtitles configures the translation of SRT and WebVTT subtitles.
+	Subtitles Subtitles `json:"subtitles"`
+
+	// CredentialCommand is a command printing the authentication key, e.g.
+	// `pass show deepl`. It is used if the key is given neither as flag nor
+	// as environment variable.
+	CredentialCommand string `json:"credential_command"`
+
+	// ServerURL overrides the URL of the DeepL API server, e.g. to use a
+	// caching gateway or a mock server.
+	ServerURL string `json:"server_url"`
+
+	// HTTP configures the connection to the DeepL API.
+	HTTP HTTP `json:"http"`
+
+	// Log configures the log file.
+	Log Log `json:"log"`
+
+	// RefreshInterval is the interval in which languages and glossaries are
+	// refreshed in the background, e.g. `15m`. Zero disables the refresh.
+	RefreshInterval Duration `json:"refresh_interval"`
+}
+
+// HTTP holds the settings of the connection to the DeepL API.
+type HTTP struct {
+	// Proxy is the URL of the HTTP(S) proxy. If empty, the proxy is taken
+	// from the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables.
+	Proxy string `json:"proxy"`
+	// CAFile is the path of a PEM file with CA certificates that are
+	// trusted in addition to the system certificates.
+	CAFile string `json:"ca_file"`
+	// Timeout is the timeout of a single request.
+	Timeout Duration `json:"timeout"`
+	// UserAgent is appended to the User-Agent header of all requests.
+	UserAgent string `json:"user_agent"`
+}
+
+// Translation holds the settings that control when the input is translated.
+type Translation struct {
+	// Mode is `auto` to translate the input after every change or `manual`
+	// to only translate it on request.
+	Mode string `json:"mode"`
+	// Delay is the time without changes after which the input is translated
+	// in auto mode.
+	Delay Duration `json:"delay"`
+	// MinChange is the number of characters that have to change since the
+	// last translation before the input is translated again in auto mode.
+	MinChange int `json:"min_change"`
+	// ContextParagraphs is the number of unchanged paragraphs before and
+	// after a changed paragraph that are translated together with it.
+	ContextParagraphs int `json:"context_paragraphs"`
+}
+
+// Log holds the logging settings.
+type Log struct {
+	// File is the path of the log file. Logging is disabled if empty.
+	File string `json:"file"`
+	// Level is the minimum level of logged records, one of `debug`, `info`,
+	// `warn` or `error`.
+	Level string `json:"level"`
+	// Payloads logs the texts and translations of requests at debug level.
+	// Otherwise only their length is logged.
+	Payloads bool `json:"payloads"`
+}
+
+// Duration is a time.Duration given as a string like `1m30s`.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid duration: %s", data)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Subtitles holds the settings for translating subtitles.
+type Subtitles struct {
+	// Merge translates cues that continue a sentence together.
+	Merge bool `json:"merge"`
+}
+
+// ClipboardWatch holds the settings of the clipboard watch mode.
+type ClipboardWatch struct {
+	// Enabled starts the application with clipboard watch mode enabled.
+	Enabled bool `json:"enabled"`
+	// WriteBack writes translations back to the clipboard.
+	WriteBack bool `json:"write_back"`
+	// MaxLength is the maximum length of copied text that is translated.
+	MaxLength int `json:"max_length"`
+}
+
+// Default returns the default configuration.
+func Default() Config {
+	return Config{
+		Clipboard: "auto",
+		ClipboardWatch: ClipboardWatch{
+			MaxLength: 5000,
+		},
+		Translation: Translation{
+			Mode:              "auto",
+			Delay:             Duration(500 * time.Millisecond),
+			MinChange:         1,
+			ContextParagraphs: 1,
+		},
+		ConfirmCharacters: 10000,
+		HTTP: HTTP{
+			Timeout: Duration(10 * time.Second),
+		},
+		Log: Log{
+			Level: "info",
+		},
+		RefreshInterval: Duration(15 * time.Minute),
+	}
+}
+
+// DefaultPath returns the path of the configuration file in the user's
+// configuration directory.
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "deepl-tui", "config.json")
+}
+
+// Load reads the configuration file at the given path.
+// Settings missing from the file keep their default value and a missing file
+// is not an error.
+func Load(path string) (Config, error) {
+	cfg := Default()
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	} else if err != nil {
+		return cfg, err
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// DefaultGlossary returns the default glossary configured for the given
+// glossary language pair.
+// If `source` is empty, only defaults for the target language are considered.
+func (c Config) DefaultGlossary(source string, target string) string {
+	for key, glossary := range c.DefaultGlossaries {
+		s, t, ok := strings.Cut(strings.ToLower(key), "-")
+		if !ok {
+			s, t = "", s
+		}
+		if s == source && t == target {
+			return glossary
+		}
+	}
+	if source != "" {
+		return c.DefaultGlossary("", target)
+	}
+	return ""
+}
@@ -0,0 +1,115 @@
+// Package credentials stores the DeepL authentication key in the Secret
+// Service keyring or in a file encrypted with a passphrase, and reads it from
+// credential helper commands.
+package credentials
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ErrNotFound is returned if no authentication key is stored.
+var ErrNotFound = errors.New("no authentication key stored")
+
+// Store stores the authentication key.
+type Store interface {
+	Name() string
+	Get() (string, error)
+	Set(key string) error
+	Delete() error
+}
+
+// PassphraseFunc asks the user for the passphrase of the encrypted file.
+// If `confirm` is true, a new passphrase is set and should be entered twice.
+type PassphraseFunc func(confirm bool) (string, error)
+
+// New returns the store with the given name, one of `keyring` or `file`.
+// The `auto` store is the keyring if it is available and the file otherwise.
+func New(name string, passphrase PassphraseFunc) (Store, error) {
+	switch name {
+	case "", "auto":
+		if KeyringAvailable() {
+			return keyringStore{}, nil
+		}
+		return NewFileStore(DefaultPath(), passphrase), nil
+	case "keyring":
+		if !KeyringAvailable() {
+			return nil, errors.New("keyring not available, install secret-tool (libsecret)")
+		}
+		return keyringStore{}, nil
+	case "file":
+		return NewFileStore(DefaultPath(), passphrase), nil
+	}
+	return nil, fmt.Errorf("unknown credential store: %s", name)
+}
+
+// Stores returns the available stores in the order in which they are
+// searched for the authentication key.
+func Stores(passphrase PassphraseFunc) []Store {
+	var stores []Store
+	if KeyringAvailable() {
+		stores = append(stores, keyringStore{})
+	}
+	return append(stores, NewFileStore(DefaultPath(), passphrase))
+}
+
+// Lookup returns the authentication key from the first store that has one
+// and the name of that store. Stores that fail, e.g. a keyring without a
+// desktop session, are skipped; their error is returned if no key is found.
+func Lookup(stores []Store) (string, string, error) {
+	var firstErr error
+	for _, s := range stores {
+		key, err := s.Get()
+		if errors.Is(err, ErrNotFound) {
+			continue
+		} else if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("error reading authentication key from %s: %w", s.Name(), err)
+			}
+			continue
+		}
+		return key, s.Name(), nil
+	}
+	if firstErr != nil {
+		return "", "", firstErr
+	}
+	return "", "", ErrNotFound
+}
+
+// Command runs a credential helper command, e.g. `pass show deepl`, and
+// returns the first line it prints.
+func Command(command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("error running credential command: %w", err)
+	}
+
+	key, _, _ := strings.Cut(string(bytes.TrimSpace(out)), "\n")
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", errors.New("credential command printed no authentication key")
+	}
+	return key, nil
+}
+
+// Mask returns the key with all but the last four characters hidden.
+func Mask(key string) string {
+	if len(key) <= 4 {
+		return strings.Repeat("*", len(key))
+	}
+	return strings.Repeat("*", 8) + key[len(key)-4:]
+}
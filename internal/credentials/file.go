@@ -0,0 +1,165 @@
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// iterations is the number of PBKDF2 iterations used to derive the
+	// encryption key from the passphrase.
+	iterations = 600000
+	// maxIterations bounds the iterations read from the credentials file, so
+	// that a tampered file cannot hang the startup.
+	maxIterations = 10 * iterations
+)
+
+// DefaultPath returns the path of the encrypted credentials file in the
+// user's configuration directory.
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "deepl-tui", "credentials.json")
+}
+
+// FileStore stores the key in a file, encrypted with AES-GCM using a key
+// derived from a passphrase.
+type FileStore struct {
+	path       string
+	passphrase PassphraseFunc
+}
+
+// encryptedFile is the content of the credentials file.
+type encryptedFile struct {
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// NewFileStore creates a store using the file at the given path.
+func NewFileStore(path string, passphrase PassphraseFunc) *FileStore {
+	return &FileStore{
+		path:       path,
+		passphrase: passphrase,
+	}
+}
+
+func (s *FileStore) Name() string {
+	return "encrypted file"
+}
+
+func (s *FileStore) Get() (string, error) {
+	if s.path == "" {
+		return "", ErrNotFound
+	}
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", ErrNotFound
+	} else if err != nil {
+		return "", err
+	}
+
+	var f encryptedFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return "", fmt.Errorf("invalid credentials file %s: %w", s.path, err)
+	}
+	if f.KDF != "pbkdf2-sha256" || f.Iterations <= 0 || f.Iterations > maxIterations {
+		return "", fmt.Errorf("unsupported credentials file %s", s.path)
+	}
+
+	passphrase, err := s.passphrase(false)
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := newGCM(passphrase, f.Salt, f.Iterations)
+	if err != nil {
+		return "", err
+	}
+	if len(f.Nonce) != gcm.NonceSize() {
+		return "", fmt.Errorf("invalid credentials file %s: wrong nonce size", s.path)
+	}
+	key, err := gcm.Open(nil, f.Nonce, f.Ciphertext, nil)
+	if err != nil {
+		return "", errors.New("wrong passphrase")
+	}
+	return string(key), nil
+}
+
+func (s *FileStore) Set(key string) error {
+	if s.path == "" {
+		return errors.New("no configuration directory")
+	}
+
+	passphrase, err := s.passphrase(true)
+	if err != nil {
+		return err
+	}
+	if passphrase == "" {
+		return errors.New("empty passphrase")
+	}
+
+	f := encryptedFile{
+		KDF:        "pbkdf2-sha256",
+		Iterations: iterations,
+		Salt:       make([]byte, 16),
+	}
+	if _, err := rand.Read(f.Salt); err != nil {
+		return err
+	}
+	gcm, err := newGCM(passphrase, f.Salt, f.Iterations)
+	if err != nil {
+		return err
+	}
+	f.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return err
+	}
+	f.Ciphertext = gcm.Seal(nil, f.Nonce, []byte(key), nil)
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func (s *FileStore) Delete() error {
+	if s.path == "" {
+		return nil
+	}
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func newGCM(passphrase string, salt []byte, iter int) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(passphrase), salt, iter, 32, sha256.New))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,79 @@
+package credentials
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// keyringAttributes identify the authentication key in the keyring.
+var keyringAttributes = []string{"service", "deepl-tui", "account", "auth-key"}
+
+// KeyringAvailable reports whether the Secret Service keyring can be used,
+// which requires the `secret-tool` utility.
+func KeyringAvailable() bool {
+	_, err := exec.LookPath("secret-tool")
+	return err == nil
+}
+
+// keyringStore stores the key in the Secret Service keyring, e.g. GNOME
+// Keyring or KWallet, using `secret-tool`.
+type keyringStore struct{}
+
+func (keyringStore) Name() string {
+	return "keyring"
+}
+
+func (keyringStore) Get() (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", append([]string{"lookup"}, keyringAttributes...)...)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && stderr.Len() == 0 {
+		// secret-tool exits with status 1 without a message if there is no
+		// matching secret
+		return "", ErrNotFound
+	} else if err != nil {
+		return "", secretToolError(err, stderr.String())
+	}
+
+	key := strings.TrimSpace(string(out))
+	if key == "" {
+		return "", ErrNotFound
+	}
+	return key, nil
+}
+
+func (keyringStore) Set(key string) error {
+	var stderr bytes.Buffer
+	args := append([]string{"store", "--label=DeepL API authentication key"}, keyringAttributes...)
+	cmd := exec.Command("secret-tool", args...)
+	cmd.Stdin = strings.NewReader(key)
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return secretToolError(err, stderr.String())
+	}
+	return nil
+}
+
+func (keyringStore) Delete() error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", append([]string{"clear"}, keyringAttributes...)...)
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return secretToolError(err, stderr.String())
+	}
+	return nil
+}
+
+func secretToolError(err error, stderr string) error {
+	if msg := strings.TrimSpace(stderr); msg != "" {
+		return errors.New("secret-tool: " + msg)
+	}
+	return err
+}
@@ -0,0 +1,264 @@
+// Package deeplv3 implements a client for the DeepL v3 glossary API, which
+// supports multilingual glossaries consisting of several dictionaries, one
+// for each language pair.
+//
+// Glossaries created using the v2 API are treated as glossaries with a single
+// dictionary.
+package deeplv3
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cluttrdev/deepl-go/deepl"
+)
+
+// Dictionary is the meta information of a glossary dictionary.
+type Dictionary struct {
+	SourceLang string `json:"source_lang"`
+	TargetLang string `json:"target_lang"`
+	EntryCount int    `json:"entry_count"`
+}
+
+// Glossary is the meta information of a multilingual glossary.
+type Glossary struct {
+	GlossaryId   string       `json:"glossary_id"`
+	Name         string       `json:"name"`
+	Dictionaries []Dictionary `json:"dictionaries"`
+	CreationTime string       `json:"creation_time"`
+}
+
+// DictionaryEntries holds the entries of a single glossary dictionary.
+type DictionaryEntries struct {
+	SourceLang string
+	TargetLang string
+	Entries    []deepl.GlossaryEntry
+}
+
+// Client calls the v3 glossary API endpoints.
+type Client struct {
+	client    deepl.HTTPClient
+	serverURL string
+	authKey   string
+}
+
+// ClientOption is a functional option for configuring the Client.
+type ClientOption func(*Client)
+
+// WithServerURL allows overriding the default server url.
+func WithServerURL(url string) ClientOption {
+	return func(c *Client) {
+		c.serverURL = url
+	}
+}
+
+// WithHTTPClient allows overriding the default http client.
+func WithHTTPClient(hc deepl.HTTPClient) ClientOption {
+	return func(c *Client) {
+		c.client = hc
+	}
+}
+
+// NewClient creates a new client.
+func NewClient(authKey string, opts ...ClientOption) *Client {
+	serverURL := deepl.ServerURLPro
+	if strings.HasSuffix(authKey, ":fx") {
+		serverURL = deepl.ServerURLFree
+	}
+
+	c := &Client{
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		serverURL: serverURL,
+		authKey:   authKey,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ListGlossaries lists all glossaries.
+func (c *Client) ListGlossaries() ([]Glossary, error) {
+	var response struct {
+		Glossaries []Glossary `json:"glossaries"`
+	}
+	if err := c.call(http.MethodGet, "v3/glossaries", nil, http.StatusOK, &response); err != nil {
+		return nil, err
+	}
+	return response.Glossaries, nil
+}
+
+// CreateGlossary creates a new glossary with the given dictionaries.
+func (c *Client) CreateGlossary(name string, dicts []DictionaryEntries) (*Glossary, error) {
+	data := struct {
+		Name         string           `json:"name"`
+		Dictionaries []dictionaryData `json:"dictionaries"`
+	}{
+		Name: name,
+	}
+	for _, d := range dicts {
+		dict, err := encodeDictionary(d)
+		if err != nil {
+			return nil, err
+		}
+		data.Dictionaries = append(data.Dictionaries, dict)
+	}
+
+	var glossary Glossary
+	if err := c.call(http.MethodPost, "v3/glossaries", data, http.StatusCreated, &glossary); err != nil {
+		return nil, err
+	}
+	return &glossary, nil
+}
+
+// RenameGlossary changes the name of a glossary.
+func (c *Client) RenameGlossary(glossaryId string, name string) error {
+	data := struct {
+		Name string `json:"name"`
+	}{
+		Name: name,
+	}
+	endpoint := fmt.Sprintf("v3/glossaries/%s", glossaryId)
+	return c.call(http.MethodPatch, endpoint, data, http.StatusOK, nil)
+}
+
+// GetEntries retrieves the entries of a single glossary dictionary.
+func (c *Client) GetEntries(glossaryId string, sourceLang string, targetLang string) ([]deepl.GlossaryEntry, error) {
+	query := url.Values{}
+	query.Set("source_lang", sourceLang)
+	query.Set("target_lang", targetLang)
+	endpoint := fmt.Sprintf("v3/glossaries/%s/entries?%s", glossaryId, query.Encode())
+
+	var response struct {
+		Dictionaries []dictionaryData `json:"dictionaries"`
+	}
+	if err := c.call(http.MethodGet, endpoint, nil, http.StatusOK, &response); err != nil {
+		return nil, err
+	}
+
+	var entries []deepl.GlossaryEntry
+	for _, d := range response.Dictionaries {
+		e, err := decodeEntries(d.Entries)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e...)
+	}
+	return entries, nil
+}
+
+// ReplaceDictionary replaces the entries of a glossary dictionary in place.
+// If the glossary has no dictionary for the language pair, it is added.
+func (c *Client) ReplaceDictionary(glossaryId string, dict DictionaryEntries) error {
+	data, err := encodeDictionary(dict)
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("v3/glossaries/%s/dictionaries", glossaryId)
+	return c.call(http.MethodPut, endpoint, data, 0, nil)
+}
+
+// DeleteDictionary removes a dictionary from a glossary.
+func (c *Client) DeleteDictionary(glossaryId string, sourceLang string, targetLang string) error {
+	query := url.Values{}
+	query.Set("source_lang", sourceLang)
+	query.Set("target_lang", targetLang)
+	endpoint := fmt.Sprintf("v3/glossaries/%s/dictionaries?%s", glossaryId, query.Encode())
+	return c.call(http.MethodDelete, endpoint, nil, http.StatusNoContent, nil)
+}
+
+type dictionaryData struct {
+	SourceLang    string `json:"source_lang"`
+	TargetLang    string `json:"target_lang"`
+	Entries       string `json:"entries"`
+	EntriesFormat string `json:"entries_format"`
+}
+
+// encodeDictionary encodes the entries of a dictionary as TSV. Terms must not
+// contain tabs or line breaks, which the API does not allow and which would
+// split or shift the entries.
+func encodeDictionary(d DictionaryEntries) (dictionaryData, error) {
+	lines := make([]string, 0, len(d.Entries))
+	for _, e := range d.Entries {
+		for _, term := range []string{e.Source, e.Target} {
+			if strings.ContainsAny(term, "\t\r\n") {
+				return dictionaryData{}, fmt.Errorf("invalid glossary term %q: contains a tab or line break", term)
+			}
+		}
+		lines = append(lines, fmt.Sprintf("%s\t%s", e.Source, e.Target))
+	}
+	return dictionaryData{
+		SourceLang:    d.SourceLang,
+		TargetLang:    d.TargetLang,
+		Entries:       strings.Join(lines, "\n"),
+		EntriesFormat: "tsv",
+	}, nil
+}
+
+func decodeEntries(tsv string) ([]deepl.GlossaryEntry, error) {
+	r := csv.NewReader(strings.NewReader(tsv))
+	r.Comma = '\t'
+	r.LazyQuotes = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]deepl.GlossaryEntry, 0, len(records))
+	for _, rec := range records {
+		if len(rec) < 2 {
+			continue
+		}
+		entries = append(entries, deepl.GlossaryEntry{Source: rec[0], Target: rec[1]})
+	}
+	return entries, nil
+}
+
+// call sends a request to the given endpoint and decodes the response into
+// `result` if it is not nil.
+// A `status` of 0 accepts any successful status code.
+func (c *Client) call(method string, endpoint string, data any, status int, result any) error {
+	var body io.Reader
+	if data != nil {
+		b, err := json.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("error encoding request data: %w", err)
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, fmt.Sprintf("%s/%s", c.serverURL, endpoint), body)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("DeepL-Auth-Key %s", c.authKey))
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if (status != 0 && res.StatusCode != status) || res.StatusCode >= 300 {
+		return fmt.Errorf("%d - %s", res.StatusCode, http.StatusText(res.StatusCode))
+	}
+
+	if result != nil {
+		if err := json.NewDecoder(res.Body).Decode(result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
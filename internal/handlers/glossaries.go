@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/cluttrdev/deepl-go/deepl"
+
+	"github.com/DeepLcom/deepl-tui/internal/deeplv3"
 )
 
 type glossary struct {
@@ -12,10 +15,67 @@ type glossary struct {
 }
 
 // GlossariesHandler manages glossaries.
+//
+// Multilingual glossaries are supported if the dictionaries have been fetched
+// using [GlossariesHandler.FetchDictionaries]. Otherwise every glossary is
+// treated as having a single dictionary for its source and target language.
 type GlossariesHandler struct {
 	languages  []deepl.LanguagePair
 	glossaries []deepl.GlossaryInfo
 	entries    map[string][]deepl.GlossaryEntry
+
+	dictionaries map[string][]deeplv3.Dictionary
+	dictEntries  map[string][]deepl.GlossaryEntry
+}
+
+// GlossariesState is a snapshot of the data of a [GlossariesHandler], which
+// can be persisted and restored later.
+type GlossariesState struct {
+	Languages    []deepl.LanguagePair             `json:"languages"`
+	Glossaries   []deepl.GlossaryInfo             `json:"glossaries"`
+	Entries      map[string][]deepl.GlossaryEntry `json:"entries,omitempty"`
+	Dictionaries map[string][]deeplv3.Dictionary  `json:"dictionaries,omitempty"`
+	DictEntries  map[string][]deepl.GlossaryEntry `json:"dictionary_entries,omitempty"`
+}
+
+// State returns a snapshot of the data of the handler.
+func (h *GlossariesHandler) State() GlossariesState {
+	return GlossariesState{
+		Languages:    h.languages,
+		Glossaries:   h.List(),
+		Entries:      h.entries,
+		Dictionaries: h.dictionaries,
+		DictEntries:  h.dictEntries,
+	}
+}
+
+// Restore replaces the data of the handler by a snapshot.
+func (h *GlossariesHandler) Restore(s GlossariesState) {
+	h.languages = s.Languages
+	h.glossaries = s.Glossaries
+	h.entries = s.Entries
+	h.dictionaries = s.Dictionaries
+	h.dictEntries = s.DictEntries
+}
+
+// Refresh replaces the glossaries, dictionaries and languages by the ones of
+// a freshly fetched snapshot. Cached entries of glossaries that still exist
+// are kept, since v2 glossaries cannot be modified. Dictionary entries are
+// dropped, since dictionaries can be modified in place.
+func (h *GlossariesHandler) Refresh(s GlossariesState) {
+	entries := make(map[string][]deepl.GlossaryEntry)
+	for id, e := range h.entries {
+		for _, g := range s.Glossaries {
+			if g.GlossaryId == id {
+				entries[id] = e
+				break
+			}
+		}
+	}
+
+	h.Restore(s)
+	h.entries = entries
+	h.dictEntries = nil
 }
 
 // FetchLanguages retreives the list of supported glossary langues pairs.
@@ -47,12 +107,18 @@ func (h *GlossariesHandler) FetchEntries(client *deepl.Translator, id string) ([
 		return nil, err
 	}
 
+	h.SetEntries(id, entries)
+	return entries, nil
+}
+
+// SetEntries stores the entries of a single glossary, e.g. after they have
+// been fetched in the background.
+func (h *GlossariesHandler) SetEntries(id string, entries []deepl.GlossaryEntry) {
 	if h.entries == nil {
 		h.entries = make(map[string][]deepl.GlossaryEntry)
 	}
 	h.entries[id] = make([]deepl.GlossaryEntry, len(entries))
 	copy(h.entries[id], entries)
-	return entries, nil
 }
 
 // List returns the list of available glossaries.
@@ -104,6 +170,26 @@ func (h *GlossariesHandler) FindName(name string) string {
 	return ""
 }
 
+// Compatible returns all glossaries that can be used to translate from the
+// given source language to the given target language.
+// If `source` is an empty string, only the target language is considered.
+func (h *GlossariesHandler) Compatible(source string, target string) []deepl.GlossaryInfo {
+	var infos []deepl.GlossaryInfo
+	for _, g := range h.glossaries {
+		if _, ok := h.DictionaryFor(g.GlossaryId, source, target); ok {
+			infos = append(infos, g)
+		}
+	}
+	return infos
+}
+
+// GlossaryLang returns the glossary language code for the given language
+// code, e.g. `en` for `EN-GB`.
+func GlossaryLang(code string) string {
+	lang, _, _ := strings.Cut(code, "-")
+	return strings.ToLower(lang)
+}
+
 // GetTargetLangs returns all supported glossary target languages for the
 // given source language.
 // If `source` is an empty string, all supported target languages are returned.
@@ -150,8 +236,8 @@ func (h *GlossariesHandler) GetSourceLangs(target string) []string {
 	return sources
 }
 
-// Create creates a new glossary.
-func (h *GlossariesHandler) Create(client *deepl.Translator, name string, source string, target string, entries [][2]string) error {
+// Create creates a new glossary and returns its meta information.
+func (h *GlossariesHandler) Create(client *deepl.Translator, name string, source string, target string, entries [][2]string) (deepl.GlossaryInfo, error) {
 	entries_ := make([]deepl.GlossaryEntry, 0, len(entries))
 	for _, entry := range entries {
 		entries_ = append(entries_, deepl.GlossaryEntry{
@@ -161,16 +247,169 @@ func (h *GlossariesHandler) Create(client *deepl.Translator, name string, source
 	}
 
 	info, err := client.CreateGlossary(name, source, target, entries_)
+	if err != nil {
+		return deepl.GlossaryInfo{}, err
+	}
+
+	if info == nil {
+		return deepl.GlossaryInfo{}, nil
+	}
+	h.glossaries = append(h.glossaries, *info)
+	return *info, nil
+}
+
+// FetchDictionaries retrieves the dictionaries of all available glossaries
+// using the v3 glossary API.
+// Multilingual glossaries which are not available through the v2 API are
+// added to the list of glossaries using their first dictionary.
+func (h *GlossariesHandler) FetchDictionaries(client *deeplv3.Client) error {
+	glossaries, err := client.ListGlossaries()
 	if err != nil {
 		return err
 	}
 
-	if info != nil {
-		h.glossaries = append(h.glossaries, *info)
+	h.dictionaries = make(map[string][]deeplv3.Dictionary, len(glossaries))
+	h.dictEntries = nil
+	for _, g := range glossaries {
+		h.dictionaries[g.GlossaryId] = g.Dictionaries
+		if _, ok := h.Get(g.GlossaryId); ok || len(g.Dictionaries) == 0 {
+			continue
+		}
+		h.glossaries = append(h.glossaries, deepl.GlossaryInfo{
+			GlossaryId:   g.GlossaryId,
+			Name:         g.Name,
+			Ready:        true,
+			SourceLang:   g.Dictionaries[0].SourceLang,
+			TargetLang:   g.Dictionaries[0].TargetLang,
+			CreationTime: g.CreationTime,
+			EntryCount:   g.Dictionaries[0].EntryCount,
+		})
 	}
 	return nil
 }
 
+// Dictionaries returns the dictionaries of a single glossary.
+func (h *GlossariesHandler) Dictionaries(id string) []deeplv3.Dictionary {
+	if dicts, ok := h.dictionaries[id]; ok {
+		d := make([]deeplv3.Dictionary, len(dicts))
+		copy(d, dicts)
+		return d
+	}
+
+	info, ok := h.Get(id)
+	if !ok {
+		return nil
+	}
+	return []deeplv3.Dictionary{{
+		SourceLang: info.SourceLang,
+		TargetLang: info.TargetLang,
+		EntryCount: info.EntryCount,
+	}}
+}
+
+// DictionaryFor returns the dictionary of a glossary that can be used to
+// translate from the given source language to the given target language.
+// If `source` is an empty string, only the target language is considered.
+func (h *GlossariesHandler) DictionaryFor(id string, source string, target string) (deeplv3.Dictionary, bool) {
+	source = GlossaryLang(source)
+	target = GlossaryLang(target)
+	for _, d := range h.Dictionaries(id) {
+		if (source == "" || d.SourceLang == source) && d.TargetLang == target {
+			return d, true
+		}
+	}
+	return deeplv3.Dictionary{}, false
+}
+
+// DictionaryEntries returns the entries of a single glossary dictionary.
+// If there is no data available for the given glossary ID and languages, the
+// second return value will be `false`.
+func (h *GlossariesHandler) DictionaryEntries(id string, source string, target string) ([]deepl.GlossaryEntry, bool) {
+	entries, ok := h.dictEntries[dictionaryKey(id, source, target)]
+	if !ok {
+		return nil, false
+	}
+
+	e := make([]deepl.GlossaryEntry, len(entries))
+	copy(e, entries)
+	return e, true
+}
+
+// FetchDictionaryEntries retrieves the entries of a single glossary dictionary
+// using the v3 glossary API.
+func (h *GlossariesHandler) FetchDictionaryEntries(client *deeplv3.Client, id string, source string, target string) ([]deepl.GlossaryEntry, error) {
+	entries, err := client.GetEntries(id, source, target)
+	if err != nil {
+		return nil, err
+	}
+
+	h.SetDictionaryEntries(id, source, target, entries)
+	return entries, nil
+}
+
+// SetDictionaryEntries stores the entries of a single glossary dictionary,
+// e.g. after they have been fetched in the background.
+func (h *GlossariesHandler) SetDictionaryEntries(id string, source string, target string, entries []deepl.GlossaryEntry) {
+	if h.dictEntries == nil {
+		h.dictEntries = make(map[string][]deepl.GlossaryEntry)
+	}
+	key := dictionaryKey(id, source, target)
+	h.dictEntries[key] = make([]deepl.GlossaryEntry, len(entries))
+	copy(h.dictEntries[key], entries)
+}
+
+// UpdateDictionary modifies a glossary in place using the v3 glossary API.
+// The glossary is renamed if `name` differs from the current name and the
+// entries of the dictionary for the given languages are replaced. If there
+// is no such dictionary, it is added to the glossary.
+func (h *GlossariesHandler) UpdateDictionary(client *deeplv3.Client, id string, name string, source string, target string, entries [][2]string) error {
+	info, ok := h.Get(id)
+	if !ok {
+		return fmt.Errorf("Unknown glossary id: %s", id)
+	}
+
+	if name != info.Name {
+		if err := client.RenameGlossary(id, name); err != nil {
+			return err
+		}
+	}
+
+	dict := deeplv3.DictionaryEntries{
+		SourceLang: source,
+		TargetLang: target,
+		Entries:    make([]deepl.GlossaryEntry, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		dict.Entries = append(dict.Entries, deepl.GlossaryEntry{
+			Source: entry[0],
+			Target: entry[1],
+		})
+	}
+	if err := client.ReplaceDictionary(id, dict); err != nil {
+		return err
+	}
+
+	delete(h.entries, id)
+	delete(h.dictEntries, dictionaryKey(id, source, target))
+	return nil
+}
+
+// DeleteDictionary removes a dictionary from a glossary using the v3 glossary
+// API.
+func (h *GlossariesHandler) DeleteDictionary(client *deeplv3.Client, id string, source string, target string) error {
+	if err := client.DeleteDictionary(id, source, target); err != nil {
+		return err
+	}
+
+	delete(h.entries, id)
+	delete(h.dictEntries, dictionaryKey(id, source, target))
+	return nil
+}
+
+func dictionaryKey(id string, source string, target string) string {
+	return fmt.Sprintf("%s/%s-%s", id, GlossaryLang(source), GlossaryLang(target))
+}
+
 // Delete deletes a single glossary.
 func (h *GlossariesHandler) Delete(client *deepl.Translator, id string) error {
 	return client.DeleteGlossary(id)
@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cluttrdev/deepl-go/deepl"
+)
+
+// GlossaryFile is a glossary definition read from a local TSV or CSV file.
+//
+// The glossary name and language pair are taken from the file name, which is
+// expected to have the form `<name>.<source>-<target>.tsv` (or `.csv`), and
+// can be overridden by header comment lines at the top of the file, e.g.
+//
+//	# name: Product terms
+//	# source_lang: en
+//	# target_lang: de
+type GlossaryFile struct {
+	Path       string
+	Name       string
+	SourceLang string
+	TargetLang string
+	Entries    []deepl.GlossaryEntry
+}
+
+// ReadGlossaryDir reads all glossary files (`*.tsv` and `*.csv`) in the given
+// directory.
+func ReadGlossaryDir(dir string) ([]GlossaryFile, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []GlossaryFile
+	names := make(map[string]string)
+	for _, e := range dirEntries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if e.IsDir() || (ext != ".tsv" && ext != ".csv") {
+			continue
+		}
+
+		f, err := ReadGlossaryFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := names[f.Name]; ok {
+			return nil, fmt.Errorf("duplicate glossary name %q in %s and %s", f.Name, other, f.Path)
+		}
+		names[f.Name] = f.Path
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// ReadGlossaryFile reads a single glossary file.
+func ReadGlossaryFile(path string) (GlossaryFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return GlossaryFile{}, err
+	}
+
+	f := GlossaryFile{Path: path}
+
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	if i := strings.LastIndex(stem, "."); i > 0 {
+		if source, target, ok := strings.Cut(stem[i+1:], "-"); ok {
+			f.Name = stem[:i]
+			f.SourceLang = strings.ToLower(source)
+			f.TargetLang = strings.ToLower(target)
+		}
+	}
+	if f.Name == "" {
+		f.Name = stem
+	}
+
+	sep := ","
+	if strings.ToLower(ext) == ".tsv" {
+		sep = "\t"
+	}
+
+	// header comments are only read at the top of the file, so that entries
+	// may start with `#`; a line with a field separator is an entry unless it
+	// sets a header field
+	rest := data
+	for len(rest) > 0 {
+		line, next, _ := bytes.Cut(rest, []byte("\n"))
+		trimmed := strings.TrimSpace(string(line))
+		if !strings.HasPrefix(trimmed, "#") {
+			break
+		}
+
+		key, value, _ := strings.Cut(strings.TrimPrefix(trimmed, "#"), ":")
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		isField := key == "name" || key == "source_lang" || key == "target_lang"
+		if !isField && strings.Contains(trimmed, sep) {
+			break
+		}
+		rest = next
+
+		switch key {
+		case "name":
+			f.Name = value
+		case "source_lang":
+			f.SourceLang = strings.ToLower(value)
+		case "target_lang":
+			f.TargetLang = strings.ToLower(value)
+		}
+	}
+
+	if f.SourceLang == "" || f.TargetLang == "" {
+		return f, fmt.Errorf("%s: missing glossary language pair", path)
+	}
+
+	// replace the header by empty lines, which are skipped, so that errors
+	// report the line numbers of the file
+	header := bytes.Count(data[:len(data)-len(rest)], []byte("\n"))
+	r := csv.NewReader(bytes.NewReader(append(bytes.Repeat([]byte("\n"), header), rest...)))
+	r.FieldsPerRecord = 2
+	if sep == "\t" {
+		r.Comma = '\t'
+		r.LazyQuotes = true
+	}
+	records, err := r.ReadAll()
+	if err != nil {
+		return f, fmt.Errorf("%s: %w", path, err)
+	}
+
+	seen := make(map[string]struct{}, len(records))
+	for _, rec := range records {
+		source := strings.TrimSpace(rec[0])
+		target := strings.TrimSpace(rec[1])
+		if source == "" || target == "" {
+			return f, fmt.Errorf("%s: empty glossary entry", path)
+		}
+		if _, ok := seen[source]; ok {
+			return f, fmt.Errorf("%s: duplicate source entry %q", path, source)
+		}
+		seen[source] = struct{}{}
+		f.Entries = append(f.Entries, deepl.GlossaryEntry{Source: source, Target: target})
+	}
+
+	return f, nil
+}
+
+// SyncAction describes what needs to be done to a remote glossary.
+type SyncAction int
+
+const (
+	SyncCreate SyncAction = iota
+	SyncUpdate
+	SyncDelete
+)
+
+func (a SyncAction) String() string {
+	switch a {
+	case SyncCreate:
+		return "create"
+	case SyncUpdate:
+		return "update"
+	case SyncDelete:
+		return "delete"
+	}
+	return "unknown"
+}
+
+// SyncStep is a single change of a sync plan.
+type SyncStep struct {
+	Action SyncAction
+	Name   string
+
+	// ID is the id of the remote glossary to update or delete.
+	ID string
+	// File is the local glossary definition to create or update from.
+	File *GlossaryFile
+
+	Added   int
+	Removed int
+	Changed int
+}
+
+func (s SyncStep) String() string {
+	switch s.Action {
+	case SyncCreate:
+		return fmt.Sprintf("+ create %q (%s -> %s, %d entries)",
+			s.Name, s.File.SourceLang, s.File.TargetLang, len(s.File.Entries))
+	case SyncUpdate:
+		return fmt.Sprintf("~ update %q (%s -> %s, +%d -%d ~%d entries)",
+			s.Name, s.File.SourceLang, s.File.TargetLang, s.Added, s.Removed, s.Changed)
+	case SyncDelete:
+		return fmt.Sprintf("- delete %q (%s)", s.Name, s.ID)
+	}
+	return ""
+}
+
+// PlanSync compares the given local glossary definitions with the remote
+// glossaries and returns the steps needed to make the remote glossaries match
+// the local ones.
+// Remote glossaries without a local definition are deleted.
+//
+// The remote glossaries and their entries are fetched using the given client.
+func (h *GlossariesHandler) PlanSync(client *deepl.Translator, files []GlossaryFile) ([]SyncStep, error) {
+	if err := h.FetchGlossaries(client); err != nil {
+		return nil, err
+	}
+
+	remote := make(map[string][]deepl.GlossaryInfo)
+	for _, info := range h.List() {
+		remote[info.Name] = append(remote[info.Name], info)
+	}
+
+	var steps []SyncStep
+	for i := range files {
+		f := &files[i]
+
+		infos := remote[f.Name]
+		delete(remote, f.Name)
+		if len(infos) == 0 {
+			steps = append(steps, SyncStep{Action: SyncCreate, Name: f.Name, File: f})
+			continue
+		}
+
+		// duplicate names are not allowed in the local definitions, so
+		// remove all but the first remote glossary
+		for _, info := range infos[1:] {
+			steps = append(steps, SyncStep{Action: SyncDelete, Name: info.Name, ID: info.GlossaryId})
+		}
+
+		info := infos[0]
+		entries, err := h.FetchEntries(client, info.GlossaryId)
+		if err != nil {
+			return nil, err
+		}
+
+		added, removed, changed := diffEntries(entries, f.Entries)
+		langChanged := info.SourceLang != f.SourceLang || info.TargetLang != f.TargetLang
+		if langChanged || added+removed+changed > 0 {
+			steps = append(steps, SyncStep{
+				Action:  SyncUpdate,
+				Name:    f.Name,
+				ID:      info.GlossaryId,
+				File:    f,
+				Added:   added,
+				Removed: removed,
+				Changed: changed,
+			})
+		}
+	}
+
+	var names []string
+	for name := range remote {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, info := range remote[name] {
+			steps = append(steps, SyncStep{Action: SyncDelete, Name: info.Name, ID: info.GlossaryId})
+		}
+	}
+
+	return steps, nil
+}
+
+// ApplySync performs the given sync steps.
+// Since glossaries cannot be modified, updates are done by creating a new
+// glossary and deleting the old one.
+func (h *GlossariesHandler) ApplySync(client *deepl.Translator, steps []SyncStep) error {
+	for _, step := range steps {
+		switch step.Action {
+		case SyncCreate, SyncUpdate:
+			f := step.File
+			entries := make([][2]string, 0, len(f.Entries))
+			for _, e := range f.Entries {
+				entries = append(entries, [2]string{e.Source, e.Target})
+			}
+			if _, err := h.Create(client, f.Name, f.SourceLang, f.TargetLang, entries); err != nil {
+				return fmt.Errorf("error creating glossary %q: %w", f.Name, err)
+			}
+			if step.Action == SyncCreate {
+				continue
+			}
+			fallthrough
+		case SyncDelete:
+			if err := h.Delete(client, step.ID); err != nil {
+				return fmt.Errorf("error deleting glossary %q: %w", step.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// diffEntries returns the number of added, removed and changed entries
+// between the old and new entries.
+func diffEntries(old []deepl.GlossaryEntry, new []deepl.GlossaryEntry) (added int, removed int, changed int) {
+	m := make(map[string]string, len(old))
+	for _, e := range old {
+		m[e.Source] = e.Target
+	}
+	for _, e := range new {
+		target, ok := m[e.Source]
+		if !ok {
+			added++
+		} else if target != e.Target {
+			changed++
+		}
+		delete(m, e.Source)
+	}
+	removed = len(m)
+	return
+}
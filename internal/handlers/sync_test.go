@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cluttrdev/deepl-go/deepl"
+)
+
+// glossaryServer fakes the glossary endpoints of the DeepL API for the given
+// remote glossaries and their entries.
+func glossaryServer(t *testing.T, infos []deepl.GlossaryInfo, entries map[string][]deepl.GlossaryEntry) *deepl.Translator {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v2/glossaries", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"glossaries": infos})
+	})
+	mux.HandleFunc("GET /v2/glossaries/{id}/entries", func(w http.ResponseWriter, r *http.Request) {
+		es, ok := entries[r.PathValue("id")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/tab-separated-values")
+		for _, e := range es {
+			fmt.Fprintf(w, "%s\t%s\n", e.Source, e.Target)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	client, err := deepl.NewTranslator("key", deepl.WithServerURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestPlanSync(t *testing.T) {
+	entries := func(pairs ...string) []deepl.GlossaryEntry {
+		var es []deepl.GlossaryEntry
+		for i := 0; i+1 < len(pairs); i += 2 {
+			es = append(es, deepl.GlossaryEntry{Source: pairs[i], Target: pairs[i+1]})
+		}
+		return es
+	}
+
+	remote := []deepl.GlossaryInfo{
+		{GlossaryId: "g1", Name: "terms", SourceLang: "en", TargetLang: "de"},
+		{GlossaryId: "g2", Name: "brands", SourceLang: "en", TargetLang: "fr"},
+		{GlossaryId: "g3", Name: "brands", SourceLang: "en", TargetLang: "fr"},
+		{GlossaryId: "g4", Name: "old", SourceLang: "en", TargetLang: "de"},
+		{GlossaryId: "g5", Name: "legacy", SourceLang: "de", TargetLang: "en"},
+	}
+	remoteEntries := map[string][]deepl.GlossaryEntry{
+		"g1": entries("car", "Auto", "house", "Haus", "tree", "Baum"),
+		"g2": entries("DeepL", "DeepL"),
+		"g3": entries("DeepL", "DeepL"),
+		"g4": entries("a", "b"),
+		"g5": entries("a", "b"),
+	}
+
+	tests := []struct {
+		name  string
+		files []GlossaryFile
+		want  []string
+	}{
+		{
+			name: "up to date",
+			files: []GlossaryFile{
+				{Name: "terms", SourceLang: "en", TargetLang: "de", Entries: entries("tree", "Baum", "car", "Auto", "house", "Haus")},
+				{Name: "brands", SourceLang: "en", TargetLang: "fr", Entries: entries("DeepL", "DeepL")},
+				{Name: "old", SourceLang: "en", TargetLang: "de", Entries: entries("a", "b")},
+				{Name: "legacy", SourceLang: "de", TargetLang: "en", Entries: entries("a", "b")},
+			},
+			want: []string{
+				`- delete "brands" (g3)`,
+			},
+		},
+		{
+			name: "changes",
+			files: []GlossaryFile{
+				{Name: "terms", SourceLang: "en", TargetLang: "de", Entries: entries("car", "Wagen", "house", "Haus", "boat", "Boot", "ship", "Schiff")},
+				{Name: "new", SourceLang: "en", TargetLang: "ja", Entries: entries("hello", "こんにちは")},
+				{Name: "legacy", SourceLang: "de", TargetLang: "fr", Entries: entries("a", "b")},
+			},
+			want: []string{
+				`~ update "terms" (en -> de, +2 -1 ~1 entries)`,
+				`+ create "new" (en -> ja, 1 entries)`,
+				`~ update "legacy" (de -> fr, +0 -0 ~0 entries)`,
+				`- delete "brands" (g2)`,
+				`- delete "brands" (g3)`,
+				`- delete "old" (g4)`,
+			},
+		},
+		{
+			name:  "no local glossaries",
+			files: nil,
+			want: []string{
+				`- delete "brands" (g2)`,
+				`- delete "brands" (g3)`,
+				`- delete "legacy" (g5)`,
+				`- delete "old" (g4)`,
+				`- delete "terms" (g1)`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := glossaryServer(t, remote, remoteEntries)
+
+			var h GlossariesHandler
+			steps, err := h.PlanSync(client, tt.files)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, s := range steps {
+				got = append(got, s.String())
+				if s.Action != SyncCreate && s.ID == "" {
+					t.Errorf("step %q has no glossary id", s)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("PlanSync() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestPlanSyncError(t *testing.T) {
+	client := glossaryServer(t, []deepl.GlossaryInfo{
+		{GlossaryId: "missing", Name: "terms", SourceLang: "en", TargetLang: "de"},
+	}, nil)
+
+	var h GlossariesHandler
+	files := []GlossaryFile{{Name: "terms", SourceLang: "en", TargetLang: "de"}}
+	if _, err := h.PlanSync(client, files); err == nil {
+		t.Error("PlanSync succeeded, want error fetching the entries")
+	}
+}
+
+func TestReadGlossaryFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		data    string
+		want    GlossaryFile
+		wantErr bool
+	}{
+		{
+			name: "tsv",
+			file: "terms.en-de.tsv",
+			data: "car\tAuto\nhouse\tHaus\n",
+			want: GlossaryFile{Name: "terms", SourceLang: "en", TargetLang: "de", Entries: []deepl.GlossaryEntry{
+				{Source: "car", Target: "Auto"}, {Source: "house", Target: "Haus"},
+			}},
+		},
+		{
+			name: "csv with header",
+			file: "terms.csv",
+			data: "# name: Product terms\n# source_lang: EN\n# target_lang: FR\n# a comment\n\"a, b\",c\n",
+			want: GlossaryFile{Name: "Product terms", SourceLang: "en", TargetLang: "fr", Entries: []deepl.GlossaryEntry{
+				{Source: "a, b", Target: "c"},
+			}},
+		},
+		{
+			name: "entries starting with #",
+			file: "tags.en-de.tsv",
+			data: "# name: tags\n#hashtag\t#Hashtag\nword\tWort\n# not a comment\t# kein Kommentar\n",
+			want: GlossaryFile{Name: "tags", SourceLang: "en", TargetLang: "de", Entries: []deepl.GlossaryEntry{
+				{Source: "#hashtag", Target: "#Hashtag"},
+				{Source: "word", Target: "Wort"},
+				{Source: "# not a comment", Target: "# kein Kommentar"},
+			}},
+		},
+		{
+			name:    "missing language pair",
+			file:    "terms.tsv",
+			data:    "car\tAuto\n",
+			wantErr: true,
+		},
+		{
+			name:    "duplicate entry",
+			file:    "terms.en-de.tsv",
+			data:    "car\tAuto\ncar\tWagen\n",
+			wantErr: true,
+		},
+		{
+			name:    "empty target",
+			file:    "terms.en-de.csv",
+			data:    "car, \n",
+			wantErr: true,
+		},
+		{
+			name:    "wrong number of fields",
+			file:    "terms.en-de.tsv",
+			data:    "car\tAuto\textra\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ReadGlossaryFile(path)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ReadGlossaryFile succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Name != tt.want.Name || got.SourceLang != tt.want.SourceLang || got.TargetLang != tt.want.TargetLang || !slices.Equal(got.Entries, tt.want.Entries) {
+				t.Errorf("ReadGlossaryFile() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadGlossaryFileLineNumbers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "terms.en-de.tsv")
+	data := "# name: terms\n# comment\ncar\tAuto\nbroken\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ReadGlossaryFile(path)
+	if err == nil || !strings.Contains(err.Error(), "line 4") {
+		t.Errorf("ReadGlossaryFile() error = %v, want error on line 4", err)
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"github.com/cluttrdev/deepl-go/deepl"
+
+	"github.com/DeepLcom/deepl-tui/internal/markdown"
+	"github.com/DeepLcom/deepl-tui/internal/subtitles"
+)
+
+// TranslateSettings holds the settings used to translate text.
+type TranslateSettings struct {
+	SourceLang string
+	TargetLang string
+	Formality  string
+	GlossaryID string
+}
+
+// Options returns the translate options for the settings.
+//
+// Since glossaries require the source language to be set, the source language
+// of the glossary dictionary matching the target language is used if a
+// glossary is set without a source language. The glossaries handler may be
+// nil, in which case the source language is left unset.
+func (s TranslateSettings) Options(glossaries *GlossariesHandler) []deepl.TranslateOption {
+	var opts []deepl.TranslateOption
+	if s.SourceLang != "" {
+		opts = append(opts, deepl.WithSourceLang(s.SourceLang))
+	} else if s.GlossaryID != "" && glossaries != nil {
+		if dict, ok := glossaries.DictionaryFor(s.GlossaryID, "", s.TargetLang); ok {
+			opts = append(opts, deepl.WithSourceLang(dict.SourceLang))
+		}
+	}
+	if s.Formality != "" {
+		opts = append(opts, deepl.WithFormality(s.Formality))
+	}
+	if s.GlossaryID != "" {
+		opts = append(opts, deepl.WithGlossaryID(s.GlossaryID))
+	}
+	return opts
+}
+
+// maxTexts is the maximum number of texts per translate request.
+const maxTexts = 50
+
+// TextTranslator returns a function that translates texts into the target
+// language and returns one translation per text. The texts are split into
+// requests of at most 50 texts.
+func TextTranslator(translator *deepl.Translator, targetLang string, opts ...deepl.TranslateOption) func(texts []string) ([]string, error) {
+	return func(texts []string) ([]string, error) {
+		return TranslateInBatches(texts, func(batch []string) ([]string, error) {
+			translations, err := translator.TranslateText(batch, targetLang, opts...)
+			if err != nil {
+				return nil, err
+			}
+			result := make([]string, 0, len(translations))
+			for _, t := range translations {
+				result = append(result, t.Text)
+			}
+			return result, nil
+		})
+	}
+}
+
+// TranslateInBatches splits the texts into batches of at most 50 texts, the
+// maximum of a single translate request, and translates them one after the
+// other. It returns the translations of all batches.
+func TranslateInBatches(texts []string, translate func(batch []string) ([]string, error)) ([]string, error) {
+	result := make([]string, 0, len(texts))
+	for start := 0; start < len(texts); start += maxTexts {
+		translations, err := translate(texts[start:min(start+maxTexts, len(texts))])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, translations...)
+	}
+	return result, nil
+}
+
+// TranslateMarkdown translates the prose of a Markdown document and leaves
+// front matter, code, URLs and HTML untouched.
+func TranslateMarkdown(translator *deepl.Translator, text string, targetLang string, opts ...deepl.TranslateOption) (string, error) {
+	opts = append(opts, deepl.WithTagHandling("xml"))
+	return markdown.Translate(text, TextTranslator(translator, targetLang, opts...))
+}
+
+// TranslateSubtitles translates the cue text of SRT or WebVTT subtitles.
+func TranslateSubtitles(translator *deepl.Translator, f *subtitles.File, targetLang string, sopts subtitles.Options, opts ...deepl.TranslateOption) error {
+	opts = append(opts, deepl.WithTagHandling("xml"))
+	return subtitles.Translate(f, TextTranslator(translator, targetLang, opts...), sopts)
+}
@@ -0,0 +1,191 @@
+package l10n
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// androidCatalog is an Android `strings.xml` resource file. The target file
+// is the source file with the texts replaced by their translations;
+// resources that are not translatable are left out.
+type androidCatalog struct {
+	data  []byte
+	units []*androidUnit
+	// skip are the byte ranges of resources that are not translatable.
+	skip []edit
+}
+
+type androidUnit struct {
+	*Unit
+	element xmlElement
+}
+
+func parseAndroid(source []byte, target []byte) (*androidCatalog, error) {
+	c := &androidCatalog{data: source}
+
+	err := walkAndroid(source, func(key string, e xmlElement) {
+		c.units = append(c.units, &androidUnit{
+			Unit: &Unit{
+				Key:     key,
+				Source:  unescapeAndroid(string(source[e.innerStart:e.innerEnd])),
+				Markup:  true,
+				Pending: true,
+			},
+			element: e,
+		})
+	}, func(e xmlElement) {
+		start, end := e.start, e.end
+		// remove the whole line if the resource is on its own line
+		if indent, ok := lineIndent(source, e.start); ok && end < len(source) && source[end] == '\n' {
+			start -= len(indent)
+			end++
+		}
+		c.skip = append(c.skip, edit{start: start, end: end})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error parsing source: %w", err)
+	}
+
+	if len(target) > 0 {
+		existing := make(map[string]string)
+		err := walkAndroid(target, func(key string, e xmlElement) {
+			existing[key] = unescapeAndroid(string(target[e.innerStart:e.innerEnd]))
+		}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing target: %w", err)
+		}
+		for _, u := range c.units {
+			if t := existing[u.Key]; strings.TrimSpace(t) != "" {
+				u.Target = t
+				u.Pending = false
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// walkAndroid calls `text` for all translatable texts of a resource file and
+// `skip` for all resources that are not translatable.
+//
+// Keys are the resource name for strings, `name[index]` for string array
+// items and `name:quantity` for plurals.
+func walkAndroid(data []byte, text func(key string, e xmlElement), skip func(e xmlElement)) error {
+	toks, err := scanXML(data)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < len(toks); i++ {
+		start, ok := toks[i].tok.(xml.StartElement)
+		if !ok || start.Name.Space != "" {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "string", "string-array", "plurals":
+		default:
+			continue
+		}
+
+		e, j := element(data, toks, i)
+		name, _ := e.attr("name")
+		if translatable, _ := e.attr("translatable"); translatable == "false" {
+			if skip != nil {
+				skip(e)
+			}
+			i = j
+			continue
+		}
+
+		if start.Name.Local == "string" {
+			text(name, e)
+			i = j
+			continue
+		}
+
+		index := 0
+		for k := i + 1; k < j; k++ {
+			s, ok := toks[k].tok.(xml.StartElement)
+			if !ok || s.Name.Local != "item" {
+				continue
+			}
+			item, end := element(data, toks, k)
+			if quantity, ok := item.attr("quantity"); ok {
+				text(name+":"+quantity, item)
+			} else {
+				text(fmt.Sprintf("%s[%d]", name, index), item)
+				index++
+			}
+			k = end
+		}
+		i = j
+	}
+	return nil
+}
+
+func (c *androidCatalog) Units() []*Unit {
+	units := make([]*Unit, len(c.units))
+	for i, u := range c.units {
+		units[i] = u.Unit
+	}
+	return units
+}
+
+func (c *androidCatalog) Bytes() ([]byte, error) {
+	edits := append([]edit(nil), c.skip...)
+	for _, u := range c.units {
+		if u.Target == "" {
+			continue
+		}
+		e := u.element
+		edits = append(edits, edit{e.innerStart, e.innerEnd, escapeAndroid(u.Target)})
+	}
+	return splice(c.data, edits), nil
+}
+
+var androidUnescaper = strings.NewReplacer(`\'`, `'`, `\"`, `"`)
+
+// unescapeAndroid removes the escaping of quotes, which would otherwise
+// confuse the translation.
+func unescapeAndroid(s string) string {
+	return androidUnescaper.Replace(s)
+}
+
+var androidTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// escapeAndroid escapes quotes outside of tags.
+func escapeAndroid(s string) string {
+	var b strings.Builder
+	pos := 0
+	for _, loc := range androidTagPattern.FindAllStringIndex(s, -1) {
+		b.WriteString(escapeAndroidText(s[pos:loc[0]]))
+		b.WriteString(s[loc[0]:loc[1]])
+		pos = loc[1]
+	}
+	b.WriteString(escapeAndroidText(s[pos:]))
+	return b.String()
+}
+
+func escapeAndroidText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			// keep existing escape sequences
+			b.WriteByte(s[i])
+			if i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			}
+		case '\'', '"':
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
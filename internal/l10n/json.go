@@ -0,0 +1,215 @@
+package l10n
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// jsonCatalog is a nested JSON i18n bundle. The target bundle has the
+// structure and key order of the source bundle.
+type jsonCatalog struct {
+	root   *jsonNode
+	indent string
+	units  []*Unit
+}
+
+// jsonNode is a JSON value that keeps the order of object keys.
+type jsonNode struct {
+	keys   []string
+	values []*jsonNode
+	array  bool
+	object bool
+
+	// str is set for string values, other scalars are kept in raw.
+	str  *string
+	raw  json.RawMessage
+	unit *Unit
+}
+
+func parseJSON(source []byte, target []byte) (*jsonCatalog, error) {
+	root, err := decodeJSON(source)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing source: %w", err)
+	}
+
+	existing := make(map[string]string)
+	if len(bytes.TrimSpace(target)) > 0 {
+		t, err := decodeJSON(target)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing target: %w", err)
+		}
+		t.walk("", func(key string, n *jsonNode) {
+			existing[key] = *n.str
+		})
+	}
+
+	c := &jsonCatalog{
+		root:   root,
+		indent: detectIndent(source),
+	}
+	root.walk("", func(key string, n *jsonNode) {
+		t := existing[key]
+		n.unit = &Unit{
+			Key:     key,
+			Source:  *n.str,
+			Target:  t,
+			Pending: t == "" && *n.str != "",
+		}
+		c.units = append(c.units, n.unit)
+	})
+	return c, nil
+}
+
+func decodeJSON(data []byte) (*jsonNode, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	n, err := decodeJSONValue(d)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := d.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return n, nil
+}
+
+func decodeJSONValue(d *json.Decoder) (*jsonNode, error) {
+	tok, err := d.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := tok.(type) {
+	case json.Delim:
+		n := &jsonNode{object: v == '{', array: v == '['}
+		for d.More() {
+			if n.object {
+				tok, err := d.Token()
+				if err != nil {
+					return nil, err
+				}
+				n.keys = append(n.keys, tok.(string))
+			}
+			child, err := decodeJSONValue(d)
+			if err != nil {
+				return nil, err
+			}
+			n.values = append(n.values, child)
+		}
+		// consume closing delimiter
+		if _, err := d.Token(); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case string:
+		return &jsonNode{str: &v}, nil
+	default:
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonNode{raw: raw}, nil
+	}
+}
+
+// walk calls f for all string values with their key path, e.g. `menu.file.open`
+// or `items[2]`.
+func (n *jsonNode) walk(key string, f func(key string, n *jsonNode)) {
+	switch {
+	case n.object:
+		for i, k := range n.keys {
+			child := k
+			if key != "" {
+				child = key + "." + k
+			}
+			n.values[i].walk(child, f)
+		}
+	case n.array:
+		for i, v := range n.values {
+			v.walk(key+"["+strconv.Itoa(i)+"]", f)
+		}
+	case n.str != nil:
+		f(key, n)
+	}
+}
+
+// detectIndent returns the indentation of the first indented line.
+func detectIndent(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed != "" && len(trimmed) < len(line) {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+	return "  "
+}
+
+func (c *jsonCatalog) Units() []*Unit {
+	return c.units
+}
+
+func (c *jsonCatalog) Bytes() ([]byte, error) {
+	var b bytes.Buffer
+	if err := c.encode(&b, c.root, ""); err != nil {
+		return nil, err
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+func (c *jsonCatalog) encode(b *bytes.Buffer, n *jsonNode, prefix string) error {
+	switch {
+	case n.object || n.array:
+		open, close := byte('{'), byte('}')
+		if n.array {
+			open, close = '[', ']'
+		}
+		b.WriteByte(open)
+		if len(n.values) == 0 {
+			b.WriteByte(close)
+			return nil
+		}
+		for i, v := range n.values {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString("\n" + prefix + c.indent)
+			if n.object {
+				if err := encodeJSONString(b, n.keys[i]); err != nil {
+					return err
+				}
+				b.WriteString(": ")
+			}
+			if err := c.encode(b, v, prefix+c.indent); err != nil {
+				return err
+			}
+		}
+		b.WriteString("\n" + prefix)
+		b.WriteByte(close)
+		return nil
+	case n.str != nil:
+		s := *n.str
+		if n.unit != nil && n.unit.Target != "" {
+			s = n.unit.Target
+		}
+		return encodeJSONString(b, s)
+	default:
+		b.Write(n.raw)
+		return nil
+	}
+}
+
+func encodeJSONString(b *bytes.Buffer, s string) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return err
+	}
+	b.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	return nil
+}
@@ -0,0 +1,292 @@
+// Package l10n reads and writes localization catalogs and translates their
+// untranslated entries.
+//
+// Supported formats are gettext PO files, nested JSON i18n bundles, XLIFF 1.2
+// and 2.0, Android `strings.xml` resources and iOS `.strings` files.
+// PO and XLIFF files contain both the source and target texts. The other
+// formats are monolingual, so the target catalog is created from the source
+// catalog and the existing target catalog, if any.
+package l10n
+
+import (
+	"fmt"
+	"html"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Format is a localization file format.
+type Format int
+
+const (
+	FormatPO Format = iota
+	FormatJSON
+	FormatXLIFF
+	FormatAndroid
+	FormatStrings
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatPO:
+		return "po"
+	case FormatJSON:
+		return "json"
+	case FormatXLIFF:
+		return "xliff"
+	case FormatAndroid:
+		return "android"
+	case FormatStrings:
+		return "strings"
+	}
+	return "unknown"
+}
+
+// Bilingual reports whether files of the format contain both source and
+// target texts.
+func (f Format) Bilingual() bool {
+	return f == FormatPO || f == FormatXLIFF
+}
+
+// DetectFormat returns the format of a file based on its name.
+func DetectFormat(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".po", ".pot":
+		return FormatPO, nil
+	case ".json":
+		return FormatJSON, nil
+	case ".xlf", ".xliff":
+		return FormatXLIFF, nil
+	case ".xml":
+		return FormatAndroid, nil
+	case ".strings":
+		return FormatStrings, nil
+	}
+	return 0, fmt.Errorf("unsupported localization file: %s", path)
+}
+
+// IsTemplate reports whether the file is a gettext template (`.pot`), which
+// must not be overwritten with the translations of one language.
+func IsTemplate(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".pot")
+}
+
+// TargetPath returns the conventional path of the translation of a
+// monolingual catalog or a gettext template, e.g. `res/values-de/strings.xml`
+// for `res/values/strings.xml`, `de.lproj/Localizable.strings` for
+// `en.lproj/Localizable.strings`, `de.json` for `en.json` and `de.po` for
+// `app.pot`.
+func TargetPath(format Format, path string, lang string) string {
+	lang = strings.ToLower(lang)
+	dir, file := filepath.Split(path)
+	parent := filepath.Base(dir)
+	switch format {
+	case FormatAndroid:
+		if l, r, ok := strings.Cut(lang, "-"); ok {
+			lang = l + "-r" + strings.ToUpper(r)
+		}
+		if parent == "values" || strings.HasPrefix(parent, "values-") {
+			return filepath.Join(filepath.Dir(filepath.Clean(dir)), "values-"+lang, file)
+		}
+	case FormatStrings:
+		if strings.HasSuffix(parent, ".lproj") {
+			return filepath.Join(filepath.Dir(filepath.Clean(dir)), lang+".lproj", file)
+		}
+	}
+	ext := filepath.Ext(file)
+	if IsTemplate(file) {
+		ext = ".po"
+	}
+	return filepath.Join(dir, lang+ext)
+}
+
+// Unit is a translatable text of a catalog.
+type Unit struct {
+	// Key identifies the unit within the catalog.
+	Key string
+	// Source is the text to translate.
+	Source string
+	// Target is the current translation.
+	Target string
+	// Markup is true if Source and Target are XML content, which may contain
+	// inline elements.
+	Markup bool
+	// Pending is true if the unit is untranslated or its translation needs
+	// review.
+	Pending bool
+
+	translated bool
+}
+
+// SetTranslation sets the translation of the unit.
+func (u *Unit) SetTranslation(text string) {
+	u.Target = text
+	u.Pending = false
+	u.translated = true
+}
+
+// Catalog is a parsed localization catalog.
+type Catalog interface {
+	// Units returns the translatable units of the catalog.
+	Units() []*Unit
+	// Bytes returns the target catalog including the translations set on
+	// the units.
+	Bytes() ([]byte, error)
+}
+
+// Parse parses a catalog. For monolingual formats, `target` is the content of
+// the existing target catalog and may be nil. It is ignored for bilingual
+// formats.
+func Parse(format Format, source []byte, target []byte) (Catalog, error) {
+	var (
+		c   Catalog
+		err error
+	)
+	switch format {
+	case FormatPO:
+		c, err = parsePO(source)
+	case FormatJSON:
+		c, err = parseJSON(source, target)
+	case FormatXLIFF:
+		c, err = parseXLIFF(source)
+	case FormatAndroid:
+		c, err = parseAndroid(source, target)
+	case FormatStrings:
+		c, err = parseStrings(source, target)
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Pending returns the units of a catalog that need to be translated.
+func Pending(c Catalog) []*Unit {
+	var units []*Unit
+	for _, u := range c.Units() {
+		if u.Pending && strings.TrimSpace(u.Source) != "" {
+			units = append(units, u)
+		}
+	}
+	return units
+}
+
+// TranslateFunc translates a list of texts containing XML markup.
+type TranslateFunc func(texts []string) ([]string, error)
+
+// Result reports the result of translating a catalog.
+type Result struct {
+	Translated int
+	// Skipped are the keys of the units whose translation lost placeholders.
+	Skipped []string
+}
+
+// Translate translates the pending units of a catalog.
+//
+// Placeholders like `%s`, `{name}` and `{{count}}` are replaced by XML
+// elements before translation, so the translate function must use XML tag
+// handling. Units whose translation does not contain all placeholders keep
+// their current translation and are reported as skipped.
+func Translate(c Catalog, translate TranslateFunc) (Result, error) {
+	var result Result
+
+	units := Pending(c)
+	if len(units) == 0 {
+		return result, nil
+	}
+
+	texts := make([]string, len(units))
+	placeholders := make([][]string, len(units))
+	for i, u := range units {
+		text := u.Source
+		if !u.Markup {
+			text = escapeXML(text)
+		}
+		texts[i], placeholders[i] = protect(text)
+	}
+
+	translations, err := translate(texts)
+	if err != nil {
+		return result, err
+	}
+	if len(translations) != len(units) {
+		return result, fmt.Errorf("expected %d translations, got %d", len(units), len(translations))
+	}
+
+	for i, u := range units {
+		text, ok := restore(translations[i], placeholders[i])
+		if !ok {
+			result.Skipped = append(result.Skipped, u.Key)
+			continue
+		}
+		if !u.Markup {
+			text = html.UnescapeString(text)
+		}
+		u.SetTranslation(text)
+		result.Translated++
+	}
+
+	return result, nil
+}
+
+// placeholderPattern matches placeholders that must not be translated:
+// printf-style format specifiers (including positional and Objective-C `%@`
+// specifiers), `{{mustache}}`, `{icu}`, `%{ruby}` and `${template}`
+// placeholders, Android `<xliff:g>` elements and escaped control characters.
+//
+// The space flag of printf is not supported, since a percent sign followed by
+// a word, e.g. `Save 20% on all items` or `100% sure`, is far more common in
+// prose than a format specifier like `% d`.
+var placeholderPattern = regexp.MustCompile(
+	`%(?:\d+\$)?[-+#0]*(?:\d+|\*)?(?:\.\d+)?(?:hh|h|ll|l|L|q|j|z|t)?[diouxXeEfFgGaAcspn@%]` +
+		`|\{\{[^{}]*\}\}` +
+		`|[%$]?\{[^{}\s]*\}` +
+		`|<xliff:g[^>]*>.*?</xliff:g>` +
+		`|\\[nt]`)
+
+// placeholderTag is the element name used to protect placeholders.
+const placeholderTag = "dph"
+
+var placeholderTagPattern = regexp.MustCompile(`<` + placeholderTag + ` id="(\d+)"\s*/>`)
+
+// protect replaces the placeholders in a text by XML elements and returns the
+// placeholders in order.
+func protect(text string) (string, []string) {
+	var placeholders []string
+	text = placeholderPattern.ReplaceAllStringFunc(text, func(s string) string {
+		placeholders = append(placeholders, s)
+		return fmt.Sprintf(`<%s id="%d"/>`, placeholderTag, len(placeholders)-1)
+	})
+	return text, placeholders
+}
+
+// restore replaces the placeholder elements in a text by the original
+// placeholders. It reports whether every placeholder was found exactly once.
+func restore(text string, placeholders []string) (string, bool) {
+	seen := make([]bool, len(placeholders))
+	ok := true
+	text = placeholderTagPattern.ReplaceAllStringFunc(text, func(s string) string {
+		m := placeholderTagPattern.FindStringSubmatch(s)
+		i, err := strconv.Atoi(m[1])
+		if err != nil || i >= len(placeholders) || seen[i] {
+			ok = false
+			return s
+		}
+		seen[i] = true
+		return placeholders[i]
+	})
+	for _, s := range seen {
+		ok = ok && s
+	}
+	return text, ok
+}
+
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func escapeXML(s string) string {
+	return xmlEscaper.Replace(s)
+}
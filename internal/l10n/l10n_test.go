@@ -0,0 +1,239 @@
+package l10n
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := map[string]Format{
+		"de.po":                  FormatPO,
+		"app.POT":                FormatPO,
+		"locales/en.json":        FormatJSON,
+		"app.xlf":                FormatXLIFF,
+		"app.xliff":              FormatXLIFF,
+		"res/values/strings.xml": FormatAndroid,
+		"en.lproj/Main.strings":  FormatStrings,
+	}
+
+	for path, want := range tests {
+		got, err := DetectFormat(path)
+		if err != nil || got != want {
+			t.Errorf("DetectFormat(%q) = %v, %v, want %v", path, got, err, want)
+		}
+	}
+
+	if _, err := DetectFormat("README.md"); err == nil {
+		t.Error("DetectFormat(README.md) succeeded, want error")
+	}
+}
+
+func TestIsTemplate(t *testing.T) {
+	tests := map[string]bool{
+		"app.pot":      true,
+		"po/APP.POT":   true,
+		"de.po":        false,
+		"pot/messages": false,
+	}
+
+	for path, want := range tests {
+		if got := IsTemplate(path); got != want {
+			t.Errorf("IsTemplate(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestTargetPath(t *testing.T) {
+	tests := []struct {
+		format Format
+		path   string
+		lang   string
+		want   string
+	}{
+		{FormatAndroid, "res/values/strings.xml", "DE", "res/values-de/strings.xml"},
+		{FormatAndroid, "res/values-en/strings.xml", "PT-BR", "res/values-pt-rBR/strings.xml"},
+		{FormatAndroid, "strings.xml", "DE", "de.xml"},
+		{FormatStrings, "App/en.lproj/Localizable.strings", "FR", "App/fr.lproj/Localizable.strings"},
+		{FormatStrings, "Localizable.strings", "FR", "fr.strings"},
+		{FormatJSON, "locales/en.json", "ES", "locales/es.json"},
+		{FormatPO, "po/app.pot", "DE", "po/de.po"},
+	}
+
+	for _, tt := range tests {
+		got := TargetPath(tt.format, filepath.FromSlash(tt.path), tt.lang)
+		if want := filepath.FromSlash(tt.want); got != want {
+			t.Errorf("TargetPath(%v, %q, %q) = %q, want %q", tt.format, tt.path, tt.lang, got, want)
+		}
+	}
+}
+
+func TestProtect(t *testing.T) {
+	tests := []struct {
+		text         string
+		protected    string
+		placeholders []string
+	}{
+		{
+			text:         "Hello %s, you have %d new messages",
+			protected:    `Hello <dph id="0"/>, you have <dph id="1"/> new messages`,
+			placeholders: []string{"%s", "%d"},
+		},
+		{
+			text:         "%1$@ of %2$.2f%%",
+			protected:    `<dph id="0"/> of <dph id="1"/><dph id="2"/>`,
+			placeholders: []string{"%1$@", "%2$.2f", "%%"},
+		},
+		{
+			text:         "Hi {{name}}, {count} items in %{folder} and ${dir}",
+			protected:    `Hi <dph id="0"/>, <dph id="1"/> items in <dph id="2"/> and <dph id="3"/>`,
+			placeholders: []string{"{{name}}", "{count}", "%{folder}", "${dir}"},
+		},
+		{
+			text:         `Line one\nLine <xliff:g id="n">%1$d</xliff:g>`,
+			protected:    `Line one<dph id="0"/>Line <dph id="1"/>`,
+			placeholders: []string{`\n`, `<xliff:g id="n">%1$d</xliff:g>`},
+		},
+		{
+			// a percent sign followed by a word is not a placeholder
+			text:      "Save 20% on all items, 100% sure",
+			protected: "Save 20% on all items, 100% sure",
+		},
+	}
+
+	for _, tt := range tests {
+		protected, placeholders := protect(tt.text)
+		if protected != tt.protected || !slices.Equal(placeholders, tt.placeholders) {
+			t.Errorf("protect(%q) = %q, %q, want %q, %q", tt.text, protected, placeholders, tt.protected, tt.placeholders)
+			continue
+		}
+		restored, ok := restore(protected, placeholders)
+		if !ok || restored != tt.text {
+			t.Errorf("restore(%q) = %q, %v, want %q, true", protected, restored, ok, tt.text)
+		}
+	}
+}
+
+func TestRestore(t *testing.T) {
+	placeholders := []string{"%s", "%d"}
+
+	tests := []struct {
+		text string
+		want string
+		ok   bool
+	}{
+		{`<dph id="1"/> Nachrichten für <dph id="0" />`, "%d Nachrichten für %s", true},
+		{`Nachrichten für <dph id="0"/>`, `Nachrichten für %s`, false},
+		{`<dph id="0"/> <dph id="0"/> <dph id="1"/>`, `%s <dph id="0"/> %d`, false},
+		{`<dph id="0"/> <dph id="1"/> <dph id="2"/>`, `%s %d <dph id="2"/>`, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := restore(tt.text, placeholders)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("restore(%q) = %q, %v, want %q, %v", tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// upper translates by upper-casing the text outside of XML tags and
+// entities.
+func upper(texts []string) ([]string, error) {
+	translations := make([]string, len(texts))
+	for i, text := range texts {
+		var b strings.Builder
+		inTag := false
+		for _, r := range text {
+			switch {
+			case r == '<' || r == '&':
+				inTag = true
+			case inTag && (r == '>' || r == ';'):
+				inTag = false
+				b.WriteRune(r)
+				continue
+			}
+			if inTag {
+				b.WriteRune(r)
+			} else {
+				b.WriteString(strings.ToUpper(string(r)))
+			}
+		}
+		translations[i] = b.String()
+	}
+	return translations, nil
+}
+
+func TestTranslate(t *testing.T) {
+	source := []byte(`{
+  "greeting": "Hello %s & welcome",
+  "done": "",
+  "nested": {
+    "markup": "<b>bold</b> {count}"
+  }
+}
+`)
+	target := []byte(`{"greeting": "Bonjour %s"}`)
+
+	c, err := Parse(FormatJSON, source, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var texts []string
+	result, err := Translate(c, func(t []string) ([]string, error) {
+		texts = t
+		return upper(t)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// texts without markup are escaped, placeholders are protected
+	if want := []string{`&lt;b&gt;bold&lt;/b&gt; <dph id="0"/>`}; !slices.Equal(texts, want) {
+		t.Errorf("translated texts = %q, want %q", texts, want)
+	}
+	if result.Translated != 1 || len(result.Skipped) != 0 {
+		t.Errorf("result = %+v, want 1 translated", result)
+	}
+
+	data, err := c.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "greeting": "Bonjour %s",
+  "done": "",
+  "nested": {
+    "markup": "<B>BOLD</B> {count}"
+  }
+}
+`
+	if string(data) != want {
+		t.Errorf("Bytes() = %s, want %s", data, want)
+	}
+}
+
+func TestTranslateSkipsLostPlaceholders(t *testing.T) {
+	c, err := Parse(FormatJSON, []byte(`{"a": "Hello %s", "b": "Bye"}`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Translate(c, func(texts []string) ([]string, error) {
+		return []string{"Hallo", "Tschüss"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Translated != 1 || !slices.Equal(result.Skipped, []string{"a"}) {
+		t.Errorf("result = %+v, want 1 translated and a skipped", result)
+	}
+	if units := Pending(c); len(units) != 1 || units[0].Key != "a" {
+		t.Errorf("Pending() = %v, want unit a", units)
+	}
+
+	if _, err := Translate(c, func(texts []string) ([]string, error) { return nil, nil }); err == nil {
+		t.Error("Translate with missing translations succeeded, want error")
+	}
+}
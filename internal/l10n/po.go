@@ -0,0 +1,490 @@
+package l10n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// poCatalog is a gettext PO file.
+//
+// Entries that are not translated keep their original lines, so that the
+// file is written back unchanged apart from the new translations.
+type poCatalog struct {
+	lines   []string
+	entries []*poEntry
+	units   []*Unit
+
+	// nplurals is the number of plural forms from the header, 0 if unknown.
+	nplurals int
+}
+
+type poEntry struct {
+	// start and end are the line range of the entry.
+	start, end int
+
+	comments []string
+	ctxt     *string
+	id       string
+	idPlural *string
+	strs     map[int]*string
+	plural   bool
+	fuzzy    bool
+
+	// units are the singular and, for plural entries, plural unit.
+	units []*Unit
+	// changed is set if the header was modified.
+	changed bool
+}
+
+func parsePO(data []byte) (*poCatalog, error) {
+	c := &poCatalog{
+		lines: strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n"),
+	}
+
+	var e *poEntry
+	// field points to the string that continuation lines are appended to
+	var field *string
+
+	finish := func(end int) {
+		if e == nil {
+			return
+		}
+		e.end = end
+		c.entries = append(c.entries, e)
+		e, field = nil, nil
+	}
+
+	for i, line := range c.lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			finish(i)
+			continue
+		case strings.HasPrefix(trimmed, "#~"):
+			// obsolete entries are kept as they are
+			finish(i)
+			continue
+		}
+
+		if e == nil {
+			e = &poEntry{start: i, strs: make(map[int]*string)}
+		}
+
+		if strings.HasPrefix(trimmed, "#") {
+			if field != nil {
+				// a comment after the strings starts a new entry
+				finish(i)
+				e = &poEntry{start: i, strs: make(map[int]*string)}
+			}
+			e.comments = append(e.comments, trimmed)
+			if strings.HasPrefix(trimmed, "#,") {
+				for _, flag := range strings.Split(trimmed[2:], ",") {
+					if strings.TrimSpace(flag) == "fuzzy" {
+						e.fuzzy = true
+					}
+				}
+			}
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, `"`) {
+			if field == nil {
+				return nil, fmt.Errorf("line %d: unexpected string", i+1)
+			}
+			s, err := poUnquote(trimmed)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			*field += s
+			continue
+		}
+
+		keyword, value, ok := strings.Cut(trimmed, " ")
+		if !ok {
+			return nil, fmt.Errorf("line %d: invalid line", i+1)
+		}
+		s, err := poUnquote(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		switch {
+		case keyword == "msgctxt":
+			if field != nil {
+				finish(i)
+				e = &poEntry{start: i, strs: make(map[int]*string)}
+			}
+			e.ctxt = &s
+			field = e.ctxt
+		case keyword == "msgid":
+			if field != nil && e.ctxt != field {
+				finish(i)
+				e = &poEntry{start: i, strs: make(map[int]*string)}
+			}
+			e.id = s
+			field = &e.id
+		case keyword == "msgid_plural":
+			e.idPlural = &s
+			e.plural = true
+			field = e.idPlural
+		case keyword == "msgstr":
+			e.strs[0] = &s
+			field = &s
+		case strings.HasPrefix(keyword, "msgstr[") && strings.HasSuffix(keyword, "]"):
+			n, err := strconv.Atoi(keyword[len("msgstr[") : len(keyword)-1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid plural index", i+1)
+			}
+			e.strs[n] = &s
+			field = &s
+		default:
+			return nil, fmt.Errorf("line %d: unknown keyword %q", i+1, keyword)
+		}
+	}
+	finish(len(c.lines))
+
+	for _, e := range c.entries {
+		if e.isHeader() {
+			c.nplurals = parseNPlurals(headerField(e.str(0), "Plural-Forms"))
+			continue
+		}
+		key := e.id
+		if e.ctxt != nil {
+			key = *e.ctxt + "\x04" + e.id
+		}
+
+		singular := &Unit{
+			Key:     key,
+			Source:  e.id,
+			Target:  e.str(0),
+			Pending: e.fuzzy || e.str(0) == "",
+		}
+		e.units = append(e.units, singular)
+		if e.plural {
+			pending := e.fuzzy
+			for n, s := range e.strs {
+				if n > 0 && *s == "" {
+					pending = true
+				}
+			}
+			e.units = append(e.units, &Unit{
+				Key:     key + "[plural]",
+				Source:  *e.idPlural,
+				Target:  e.str(1),
+				Pending: pending,
+			})
+		}
+		c.units = append(c.units, e.units...)
+	}
+
+	return c, nil
+}
+
+// isHeader reports whether the entry is the header entry.
+func (e *poEntry) isHeader() bool {
+	return e.id == "" && e.ctxt == nil
+}
+
+// str returns the msgstr with index `n`.
+func (e *poEntry) str(n int) string {
+	if s, ok := e.strs[n]; ok {
+		return *s
+	}
+	return ""
+}
+
+func (c *poCatalog) Units() []*Unit {
+	return c.units
+}
+
+func (c *poCatalog) Bytes() ([]byte, error) {
+	var out []string
+	pos := 0
+	for _, e := range c.entries {
+		if !e.translated() && !e.changed {
+			continue
+		}
+		out = append(out, c.lines[pos:e.start]...)
+		out = append(out, e.render(c.nplurals)...)
+		if e.start == e.end {
+			// new header
+			out = append(out, "")
+		}
+		pos = e.end
+	}
+	out = append(out, c.lines[pos:]...)
+	return []byte(strings.Join(out, "\n")), nil
+}
+
+func (e *poEntry) translated() bool {
+	for _, u := range e.units {
+		if u.translated {
+			return true
+		}
+	}
+	return false
+}
+
+// render returns the lines of a translated entry or the header. The fuzzy flag
+// and the previous msgid comments are removed.
+//
+// The translation of the plural source text is used for all plural forms of
+// the target language but the first. If the language has other than two
+// plural forms, this is at best an approximation and the entry is marked as
+// fuzzy.
+func (e *poEntry) render(nplurals int) []string {
+	fuzzy := false
+	for _, u := range e.units {
+		fuzzy = fuzzy || u.Pending
+	}
+	n := nplurals
+	if n == 0 {
+		n = max(len(e.strs), 2)
+	}
+	if e.plural && n != 2 {
+		fuzzy = true
+	}
+
+	var lines []string
+	fuzzyFlag := false
+	for _, c := range e.comments {
+		if strings.HasPrefix(c, "#|") && !fuzzy {
+			continue
+		}
+		if strings.HasPrefix(c, "#,") {
+			var flags []string
+			for _, flag := range strings.Split(c[2:], ",") {
+				if flag = strings.TrimSpace(flag); flag != "" && flag != "fuzzy" {
+					flags = append(flags, flag)
+				}
+			}
+			if fuzzy {
+				flags = append([]string{"fuzzy"}, flags...)
+				fuzzyFlag = true
+			}
+			if len(flags) == 0 {
+				continue
+			}
+			c = "#, " + strings.Join(flags, ", ")
+		}
+		lines = append(lines, c)
+	}
+	if fuzzy && !fuzzyFlag {
+		lines = append(lines, "#, fuzzy")
+	}
+
+	if e.ctxt != nil {
+		lines = append(lines, poField("msgctxt", *e.ctxt)...)
+	}
+	lines = append(lines, poField("msgid", e.id)...)
+	if !e.plural {
+		target := e.str(0)
+		if len(e.units) > 0 {
+			target = e.units[0].Target
+		}
+		return append(lines, poField("msgstr", target)...)
+	}
+
+	lines = append(lines, poField("msgid_plural", *e.idPlural)...)
+	for i := 0; i < n; i++ {
+		s := e.str(i)
+		if i == 0 {
+			s = e.units[0].Target
+		} else if e.units[1].translated {
+			s = e.units[1].Target
+		}
+		lines = append(lines, poField(fmt.Sprintf("msgstr[%d]", i), s)...)
+	}
+	return lines
+}
+
+// SetLanguage prepares a catalog created from a gettext template for the
+// given DeepL target language: the `Language` and `Plural-Forms` header fields
+// are set and the header is no longer marked as fuzzy. A header is added if
+// the catalog has none. Catalogs of other formats are not changed.
+func SetLanguage(c Catalog, lang string) {
+	po, ok := c.(*poCatalog)
+	if !ok {
+		return
+	}
+
+	var header *poEntry
+	for _, e := range po.entries {
+		if e.isHeader() {
+			header = e
+			break
+		}
+	}
+	if header == nil {
+		empty := ""
+		header = &poEntry{
+			strs: map[int]*string{0: &empty},
+		}
+		po.entries = append([]*poEntry{header}, po.entries...)
+	}
+
+	str := header.str(0)
+	if ct := headerField(str, "Content-Type"); ct == "" || strings.Contains(ct, "CHARSET") {
+		str = setHeaderField(str, "Content-Type", "text/plain; charset=UTF-8")
+	}
+	str = setHeaderField(str, "Language", poLanguage(lang))
+	pluralForms := poPluralForms(lang)
+	str = setHeaderField(str, "Plural-Forms", pluralForms)
+	header.strs[0] = &str
+	header.fuzzy = false
+	header.changed = true
+	po.nplurals = parseNPlurals(pluralForms)
+}
+
+// headerField returns the value of a field of the PO header.
+func headerField(header string, name string) string {
+	for _, line := range strings.Split(header, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(key), name) {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// setHeaderField sets the value of a field of the PO header, adding the field
+// if it doesn't exist.
+func setHeaderField(header string, name string, value string) string {
+	lines := strings.SplitAfter(header, "\n")
+	for i, line := range lines {
+		key, _, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(key), name) {
+			lines[i] = name + ": " + value + "\n"
+			return strings.Join(lines, "")
+		}
+	}
+	if header != "" && !strings.HasSuffix(header, "\n") {
+		header += "\n"
+	}
+	return header + name + ": " + value + "\n"
+}
+
+// parseNPlurals returns the number of plural forms of a `Plural-Forms` header
+// value or 0 if it is invalid.
+func parseNPlurals(pluralForms string) int {
+	for _, part := range strings.Split(pluralForms, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok && strings.TrimSpace(key) == "nplurals" {
+			if n, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && n > 0 {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// poLanguage returns the gettext language code of a DeepL language code,
+// e.g. `pt_BR` for `PT-BR` and `zh_Hans` for `ZH-HANS`.
+func poLanguage(lang string) string {
+	base, region, ok := strings.Cut(lang, "-")
+	base = strings.ToLower(base)
+	switch {
+	case !ok:
+		return base
+	case len(region) == 4:
+		// script
+		return base + "_" + strings.ToUpper(region[:1]) + strings.ToLower(region[1:])
+	}
+	return base + "_" + strings.ToUpper(region)
+}
+
+// pluralForms are the gettext plural forms of the DeepL target languages
+// which don't use the English rule.
+var pluralForms = map[string]string{
+	"ar": "nplurals=6; plural=(n==0 ? 0 : n==1 ? 1 : n==2 ? 2 : n%100>=3 && n%100<=10 ? 3 : n%100>=11 ? 4 : 5);",
+	"cs": "nplurals=3; plural=(n==1 ? 0 : n>=2 && n<=4 ? 1 : 2);",
+	"fr": "nplurals=2; plural=(n > 1);",
+	"id": "nplurals=1; plural=0;",
+	"ja": "nplurals=1; plural=0;",
+	"ko": "nplurals=1; plural=0;",
+	"lt": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"lv": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n != 0 ? 1 : 2);",
+	"pl": "nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"ro": "nplurals=3; plural=(n==1 ? 0 : (n==0 || (n%100 > 0 && n%100 < 20)) ? 1 : 2);",
+	"ru": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"sk": "nplurals=3; plural=(n==1 ? 0 : n>=2 && n<=4 ? 1 : 2);",
+	"sl": "nplurals=4; plural=(n%100==1 ? 0 : n%100==2 ? 1 : n%100==3 || n%100==4 ? 2 : 3);",
+	"th": "nplurals=1; plural=0;",
+	"uk": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"vi": "nplurals=1; plural=0;",
+	"zh": "nplurals=1; plural=0;",
+}
+
+// poPluralForms returns the `Plural-Forms` header value of a DeepL language.
+func poPluralForms(lang string) string {
+	base, region, _ := strings.Cut(strings.ToLower(lang), "-")
+	if base == "pt" && region == "br" {
+		return "nplurals=2; plural=(n > 1);"
+	}
+	if s, ok := pluralForms[base]; ok {
+		return s
+	}
+	return "nplurals=2; plural=(n != 1);"
+}
+
+// poField formats a keyword and string, splitting multi-line strings after
+// each newline like gettext does.
+func poField(keyword string, s string) []string {
+	if !strings.Contains(strings.TrimSuffix(s, "\n"), "\n") {
+		return []string{keyword + " " + poQuote(s)}
+	}
+	lines := []string{keyword + ` ""`}
+	for _, line := range strings.SplitAfter(s, "\n") {
+		if line != "" {
+			lines = append(lines, poQuote(line))
+		}
+	}
+	return lines
+}
+
+var poEscaper = strings.NewReplacer(
+	`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`,
+)
+
+func poQuote(s string) string {
+	return `"` + poEscaper.Replace(s) + `"`
+}
+
+func poUnquote(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("invalid string %s", s)
+	}
+	s = s[1 : len(s)-1]
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i == len(s) {
+			return "", fmt.Errorf("invalid escape sequence")
+		}
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
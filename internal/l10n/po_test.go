@@ -0,0 +1,264 @@
+package l10n
+
+import (
+	"strings"
+	"testing"
+)
+
+const testPOT = `# Translations of the app.
+msgid ""
+msgstr ""
+"Project-Id-Version: app 1.0\n"
+"Content-Type: text/plain; charset=CHARSET\n"
+
+#: main.go:10
+msgid "Hello"
+msgstr ""
+
+#, c-format
+msgctxt "menu"
+msgid "Open %s"
+msgstr ""
+
+msgid "One file"
+msgid_plural "%d files"
+msgstr[0] ""
+msgstr[1] ""
+
+#~ msgid "Obsolete"
+#~ msgstr "Veraltet"
+`
+
+func TestParsePO(t *testing.T) {
+	c, err := parsePO([]byte(`msgid ""
+msgstr "Plural-Forms: nplurals=3; plural=(n==1 ? 0 : 2);\n"
+
+msgid "Hello"
+msgstr "Hallo"
+
+#, fuzzy
+#| msgid "Old"
+msgid "New"
+msgstr "Alt"
+
+msgctxt "menu"
+msgid ""
+"Multi "
+"line"
+msgstr ""
+
+msgid "One file"
+msgid_plural "%d files"
+msgstr[0] "Eine Datei"
+msgstr[1] ""
+msgstr[2] ""
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.nplurals != 3 {
+		t.Errorf("nplurals = %d, want 3", c.nplurals)
+	}
+
+	want := []Unit{
+		{Key: "Hello", Source: "Hello", Target: "Hallo"},
+		{Key: "New", Source: "New", Target: "Alt", Pending: true},
+		{Key: "menu\x04Multi line", Source: "Multi line", Pending: true},
+		{Key: "One file", Source: "One file", Target: "Eine Datei"},
+		{Key: "One file[plural]", Source: "%d files", Pending: true},
+	}
+	units := c.Units()
+	if len(units) != len(want) {
+		t.Fatalf("got %d units, want %d", len(units), len(want))
+	}
+	for i, u := range units {
+		if *u != want[i] {
+			t.Errorf("unit %d = %+v, want %+v", i, *u, want[i])
+		}
+	}
+}
+
+func TestParsePOErrors(t *testing.T) {
+	tests := map[string]string{
+		"unknown keyword":       "msgfoo \"x\"",
+		"unexpected string":     "\"x\"",
+		"invalid string":        "msgid x",
+		"invalid plural index":  "msgid \"a\"\nmsgstr[x] \"b\"",
+		"invalid escape":        "msgid \"a\\\"",
+		"keyword without value": "msgid",
+	}
+
+	for name, data := range tests {
+		if _, err := parsePO([]byte(data)); err == nil {
+			t.Errorf("%s: parsePO(%q) succeeded, want error", name, data)
+		}
+	}
+}
+
+func TestPOBytes(t *testing.T) {
+	data := "msgid \"\"\nmsgstr \"\"\n\"Language: de\\n\"\n\n" +
+		"#, fuzzy, c-format\n#| msgid \"Old %s\"\nmsgid \"New %s\"\nmsgstr \"Alt %s\"\n\n" +
+		"msgid \"Keep\"\nmsgstr   \"Behalten\"\n\n" +
+		"msgid \"Multi\\nline\"\nmsgstr \"\"\r\n"
+
+	c, err := parsePO([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing translated, the file is written unchanged apart from line
+	// endings
+	got, err := c.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.ReplaceAll(data, "\r\n", "\n"); string(got) != want {
+		t.Errorf("Bytes() = %q, want %q", got, want)
+	}
+
+	units := c.Units()
+	units[0].SetTranslation("Neu %s")
+	units[2].SetTranslation("Mehrere\nZeilen")
+
+	got, err = c.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "msgid \"\"\nmsgstr \"\"\n\"Language: de\\n\"\n\n" +
+		"#, c-format\nmsgid \"New %s\"\nmsgstr \"Neu %s\"\n\n" +
+		"msgid \"Keep\"\nmsgstr   \"Behalten\"\n\n" +
+		"msgid \"\"\n\"Multi\\n\"\n\"line\"\nmsgstr \"\"\n\"Mehrere\\n\"\n\"Zeilen\"\n"
+	if string(got) != want {
+		t.Errorf("Bytes() = %q, want %q", got, want)
+	}
+}
+
+func TestSetLanguage(t *testing.T) {
+	tests := []struct {
+		lang        string
+		language    string
+		pluralForms string
+		plurals     string
+	}{
+		{
+			lang:        "DE",
+			language:    "de",
+			pluralForms: "nplurals=2; plural=(n != 1);",
+			plurals:     "msgstr[0] \"ONE FILE\"\nmsgstr[1] \"%d FILES\"\n",
+		},
+		{
+			lang:        "PT-BR",
+			language:    "pt_BR",
+			pluralForms: "nplurals=2; plural=(n > 1);",
+			plurals:     "msgstr[0] \"ONE FILE\"\nmsgstr[1] \"%d FILES\"\n",
+		},
+		{
+			lang:        "ZH-HANS",
+			language:    "zh_Hans",
+			pluralForms: "nplurals=1; plural=0;",
+			plurals:     "msgstr[0] \"ONE FILE\"\n",
+		},
+		{
+			lang:        "PL",
+			language:    "pl",
+			pluralForms: "nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+			plurals:     "msgstr[0] \"ONE FILE\"\nmsgstr[1] \"%d FILES\"\nmsgstr[2] \"%d FILES\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			c, err := Parse(FormatPO, []byte(testPOT), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			SetLanguage(c, tt.lang)
+			if _, err := Translate(c, upper); err != nil {
+				t.Fatal(err)
+			}
+			data, err := c.Bytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := string(data)
+
+			header := "msgid \"\"\nmsgstr \"\"\n" +
+				"\"Project-Id-Version: app 1.0\\n\"\n" +
+				"\"Content-Type: text/plain; charset=UTF-8\\n\"\n" +
+				"\"Language: " + tt.language + "\\n\"\n" +
+				"\"Plural-Forms: " + tt.pluralForms + "\\n\"\n"
+			if !strings.HasPrefix(got, "# Translations of the app.\n"+header+"\n") {
+				t.Errorf("header not set:\n%s", got)
+			}
+
+			plural := "msgid \"One file\"\nmsgid_plural \"%d files\"\n" + tt.plurals
+			if n := parseNPlurals(tt.pluralForms); n != 2 {
+				// the plural forms are only an approximation
+				plural = "#, fuzzy\n" + plural
+			}
+			if !strings.Contains(got, "\n\n"+plural+"\n") {
+				t.Errorf("plural entry missing, want %q in:\n%s", plural, got)
+			}
+
+			for _, s := range []string{
+				"#: main.go:10\nmsgid \"Hello\"\nmsgstr \"HELLO\"\n",
+				"#, c-format\nmsgctxt \"menu\"\nmsgid \"Open %s\"\nmsgstr \"OPEN %s\"\n",
+				"#~ msgid \"Obsolete\"\n#~ msgstr \"Veraltet\"\n",
+			} {
+				if !strings.Contains(got, s) {
+					t.Errorf("missing %q in:\n%s", s, got)
+				}
+			}
+
+			// the written catalog can be read again
+			po, err := parsePO(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := parseNPlurals(tt.pluralForms); po.nplurals != want {
+				t.Errorf("nplurals = %d, want %d", po.nplurals, want)
+			}
+		})
+	}
+}
+
+func TestSetLanguageWithoutHeader(t *testing.T) {
+	c, err := Parse(FormatPO, []byte("#, fuzzy\nmsgid \"Hello\"\nmsgstr \"\"\n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetLanguage(c, "FR")
+
+	data, err := c.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "msgid \"\"\nmsgstr \"\"\n" +
+		"\"Content-Type: text/plain; charset=UTF-8\\n\"\n" +
+		"\"Language: fr\\n\"\n" +
+		"\"Plural-Forms: nplurals=2; plural=(n > 1);\\n\"\n\n" +
+		"#, fuzzy\nmsgid \"Hello\"\nmsgstr \"\"\n"
+	if string(data) != want {
+		t.Errorf("Bytes() = %q, want %q", data, want)
+	}
+}
+
+func TestPOUnquote(t *testing.T) {
+	tests := map[string]string{
+		`""`:                   "",
+		`"plain"`:              "plain",
+		`"a\"b\\c"`:            `a"b\c`,
+		`"tab\there\nnewline"`: "tab\there\nnewline",
+	}
+
+	for quoted, want := range tests {
+		got, err := poUnquote(quoted)
+		if err != nil || got != want {
+			t.Errorf("poUnquote(%s) = %q, %v, want %q", quoted, got, err, want)
+		}
+		if back := poQuote(want); back != quoted {
+			t.Errorf("poQuote(%q) = %s, want %s", want, back, quoted)
+		}
+	}
+}
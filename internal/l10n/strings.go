@@ -0,0 +1,216 @@
+package l10n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// stringsCatalog is an iOS/macOS `.strings` file. The target file is the
+// source file with the values replaced by their translations, so comments
+// for translators are kept.
+type stringsCatalog struct {
+	data  []byte
+	units []*stringsUnit
+}
+
+type stringsUnit struct {
+	*Unit
+	// start and end are the byte range of the quoted value.
+	start, end int
+}
+
+type stringsPair struct {
+	key, value string
+	start, end int
+}
+
+func parseStrings(source []byte, target []byte) (*stringsCatalog, error) {
+	pairs, err := scanStrings(string(source))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing source: %w", err)
+	}
+
+	existing := make(map[string]string)
+	if len(target) > 0 {
+		targetPairs, err := scanStrings(string(target))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing target: %w", err)
+		}
+		for _, p := range targetPairs {
+			existing[p.key] = p.value
+		}
+	}
+
+	c := &stringsCatalog{data: source}
+	for _, p := range pairs {
+		t := existing[p.key]
+		c.units = append(c.units, &stringsUnit{
+			Unit: &Unit{
+				Key:     p.key,
+				Source:  p.value,
+				Target:  t,
+				Pending: t == "",
+			},
+			start: p.start,
+			end:   p.end,
+		})
+	}
+	return c, nil
+}
+
+// scanStrings returns the `"key" = "value";` pairs of a strings file.
+func scanStrings(s string) ([]stringsPair, error) {
+	var pairs []stringsPair
+	pos := 0
+	if strings.HasPrefix(s, "\uFEFF") {
+		// skip the byte order mark
+		pos = len("\uFEFF")
+	}
+
+	skipSpace := func() error {
+		for pos < len(s) {
+			switch {
+			case strings.HasPrefix(s[pos:], "/*"):
+				end := strings.Index(s[pos+2:], "*/")
+				if end < 0 {
+					return fmt.Errorf("unterminated comment")
+				}
+				pos += end + 4
+			case strings.HasPrefix(s[pos:], "//"):
+				end := strings.IndexByte(s[pos:], '\n')
+				if end < 0 {
+					pos = len(s)
+				} else {
+					pos += end + 1
+				}
+			case strings.ContainsRune(" \t\r\n", rune(s[pos])):
+				pos++
+			default:
+				return nil
+			}
+		}
+		return nil
+	}
+
+	token := func() (string, int, int, error) {
+		if err := skipSpace(); err != nil {
+			return "", 0, 0, err
+		}
+		start := pos
+		if pos < len(s) && s[pos] == '"' {
+			for pos++; pos < len(s) && s[pos] != '"'; pos++ {
+				if s[pos] == '\\' {
+					pos++
+				}
+			}
+			if pos >= len(s) {
+				return "", 0, 0, fmt.Errorf("unterminated string at offset %d", start)
+			}
+			pos++
+			value, err := unquoteStrings(s[start+1 : pos-1])
+			return value, start, pos, err
+		}
+		for pos < len(s) && !strings.ContainsRune(" \t\r\n=;\"", rune(s[pos])) {
+			pos++
+		}
+		if pos == start {
+			return "", 0, 0, fmt.Errorf("unexpected character at offset %d", start)
+		}
+		return s[start:pos], start, pos, nil
+	}
+
+	expect := func(c byte) error {
+		if err := skipSpace(); err != nil {
+			return err
+		}
+		if pos >= len(s) || s[pos] != c {
+			return fmt.Errorf("expected %q at offset %d", c, pos)
+		}
+		pos++
+		return nil
+	}
+
+	for {
+		if err := skipSpace(); err != nil {
+			return nil, err
+		}
+		if pos >= len(s) {
+			return pairs, nil
+		}
+
+		key, _, _, err := token()
+		if err != nil {
+			return nil, err
+		}
+		if err := expect('='); err != nil {
+			return nil, err
+		}
+		value, start, end, err := token()
+		if err != nil {
+			return nil, err
+		}
+		if err := expect(';'); err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, stringsPair{key: key, value: value, start: start, end: end})
+	}
+}
+
+func unquoteStrings(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i == len(s) {
+			return "", fmt.Errorf("invalid escape sequence")
+		}
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'U', 'u':
+			if i+4 >= len(s) {
+				return "", fmt.Errorf("invalid unicode escape sequence")
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid unicode escape sequence")
+			}
+			b.WriteRune(rune(r))
+			i += 4
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+var stringsEscaper = strings.NewReplacer(
+	`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`,
+)
+
+func (c *stringsCatalog) Units() []*Unit {
+	units := make([]*Unit, len(c.units))
+	for i, u := range c.units {
+		units[i] = u.Unit
+	}
+	return units
+}
+
+func (c *stringsCatalog) Bytes() ([]byte, error) {
+	var edits []edit
+	for _, u := range c.units {
+		if u.Target == "" {
+			continue
+		}
+		edits = append(edits, edit{u.start, u.end, `"` + stringsEscaper.Replace(u.Target) + `"`})
+	}
+	return splice(c.data, edits), nil
+}
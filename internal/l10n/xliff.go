@@ -0,0 +1,184 @@
+package l10n
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// xliffCatalog is an XLIFF 1.2 or 2.0 file.
+//
+// Translations are spliced into the original document, so everything but the
+// translated targets is written back unchanged.
+type xliffCatalog struct {
+	data    []byte
+	version string
+	units   []*xliffUnit
+}
+
+type xliffUnit struct {
+	*Unit
+
+	// segment is the `<segment>` element of XLIFF 2.0 units.
+	segment *xmlElement
+	source  xmlElement
+	target  *xmlElement
+}
+
+func parseXLIFF(data []byte) (*xliffCatalog, error) {
+	toks, err := scanXML(data)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &xliffCatalog{data: data}
+
+	var (
+		id       string
+		skip     bool
+		segments int
+		segment  *xmlElement
+		current  *xliffUnit
+	)
+	finish := func() {
+		if current != nil && !skip {
+			c.units = append(c.units, current)
+		}
+		current = nil
+	}
+
+	for i := 0; i < len(toks); i++ {
+		switch tok := toks[i].tok.(type) {
+		case xml.StartElement:
+			switch tok.Name.Local {
+			case "xliff":
+				e, _ := element(data, toks, i)
+				c.version, _ = e.attr("version")
+			case "trans-unit", "unit":
+				e, _ := element(data, toks, i)
+				id, _ = e.attr("id")
+				translate, _ := e.attr("translate")
+				skip = translate == "no"
+				segments = 0
+				segment = nil
+			case "segment":
+				finish()
+				e, _ := element(data, toks, i)
+				segment = &e
+			case "source":
+				finish()
+				e, j := element(data, toks, i)
+				key := id
+				if segment != nil {
+					if segments > 0 {
+						key = fmt.Sprintf("%s#%d", id, segments)
+					}
+					segments++
+				}
+				current = &xliffUnit{
+					Unit: &Unit{
+						Key:    key,
+						Source: string(data[e.innerStart:e.innerEnd]),
+						Markup: true,
+					},
+					segment: segment,
+					source:  e,
+				}
+				i = j
+			case "target":
+				e, j := element(data, toks, i)
+				if current != nil && current.target == nil {
+					current.target = &e
+				}
+				i = j
+			case "seg-source", "alt-trans", "ignorable", "note", "notes", "originalData":
+				// skip content that may contain source or target elements
+				// which must not be translated
+				_, j := element(data, toks, i)
+				i = j
+			}
+		case xml.EndElement:
+			switch tok.Name.Local {
+			case "trans-unit", "unit", "segment":
+				finish()
+			}
+		}
+	}
+
+	for _, u := range c.units {
+		u.init(data, c.version)
+	}
+	return c, nil
+}
+
+// init sets the target text and whether the unit needs to be translated.
+func (u *xliffUnit) init(data []byte, version string) {
+	if u.target == nil {
+		u.Pending = true
+		return
+	}
+	u.Target = string(data[u.target.innerStart:u.target.innerEnd])
+	if strings.TrimSpace(u.Target) == "" {
+		u.Pending = true
+		return
+	}
+
+	if strings.HasPrefix(version, "2") {
+		state := ""
+		if u.segment != nil {
+			state, _ = u.segment.attr("state")
+		}
+		u.Pending = state == "initial"
+		return
+	}
+
+	state, _ := u.target.attr("state")
+	u.Pending = state == "new" || strings.HasPrefix(state, "needs-")
+}
+
+func (c *xliffCatalog) Units() []*Unit {
+	units := make([]*Unit, len(c.units))
+	for i, u := range c.units {
+		units[i] = u.Unit
+	}
+	return units
+}
+
+func (c *xliffCatalog) Bytes() ([]byte, error) {
+	v2 := strings.HasPrefix(c.version, "2")
+
+	var edits []edit
+	for _, u := range c.units {
+		if !u.translated {
+			continue
+		}
+
+		// XLIFF 2.0 keeps the state on the segment, XLIFF 1.2 on the target
+		tag := `<target state="translated">`
+		if v2 {
+			tag = "<target>"
+			if u.segment != nil {
+				edits = append(edits, edit{u.segment.start, u.segment.innerStart, startTag(*u.segment, "state", "translated")})
+			}
+		}
+
+		if u.target != nil {
+			if v2 {
+				tag = startTag(*u.target, "", "")
+			} else {
+				tag = startTag(*u.target, "state", "translated")
+			}
+			edits = append(edits, edit{u.target.start, u.target.end, tag + u.Target + "</target>"})
+			continue
+		}
+
+		// put the new target on its own line if the source is on its
+		// own line
+		if indent, ok := lineIndent(c.data, u.source.start); ok {
+			tag = "\n" + indent + tag
+		}
+		edits = append(edits, edit{u.source.end, u.source.end, tag + u.Target + "</target>"})
+	}
+
+	return splice(c.data, edits), nil
+}
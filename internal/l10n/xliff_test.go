@@ -0,0 +1,125 @@
+package l10n
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestXLIFF(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		pending []string
+		want    string
+	}{
+		{
+			name: "xliff 1.2",
+			data: `<?xml version="1.0" encoding="UTF-8"?>
+<xliff version="1.2">
+  <file source-language="en" target-language="de">
+    <body>
+      <trans-unit id="hello">
+        <source>Hello <g id="1">world</g></source>
+      </trans-unit>
+      <trans-unit id="done">
+        <source>Done</source>
+        <target state="translated">Fertig</target>
+      </trans-unit>
+      <trans-unit id="review">
+        <source>Review %s</source>
+        <target state="needs-review-translation">Prüfen %s</target>
+        <alt-trans><source>Ignored</source></alt-trans>
+      </trans-unit>
+      <trans-unit id="fixed" translate="no">
+        <source>DeepL</source>
+      </trans-unit>
+    </body>
+  </file>
+</xliff>
+`,
+			pending: []string{"hello", "review"},
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<xliff version="1.2">
+  <file source-language="en" target-language="de">
+    <body>
+      <trans-unit id="hello">
+        <source>Hello <g id="1">world</g></source>
+        <target state="translated">HELLO <g id="1">WORLD</g></target>
+      </trans-unit>
+      <trans-unit id="done">
+        <source>Done</source>
+        <target state="translated">Fertig</target>
+      </trans-unit>
+      <trans-unit id="review">
+        <source>Review %s</source>
+        <target state="translated">REVIEW %s</target>
+        <alt-trans><source>Ignored</source></alt-trans>
+      </trans-unit>
+      <trans-unit id="fixed" translate="no">
+        <source>DeepL</source>
+      </trans-unit>
+    </body>
+  </file>
+</xliff>
+`,
+		},
+		{
+			name: "xliff 2.0",
+			data: `<xliff xmlns="urn:oasis:names:tc:xliff:document:2.0" version="2.0" srcLang="en" trgLang="de">
+  <file id="f1">
+    <unit id="u1">
+      <segment><source>First</source></segment>
+      <segment state="initial"><source>Second</source><target>Zweite</target></segment>
+    </unit>
+    <unit id="u2">
+      <notes><note>Not translated</note></notes>
+      <segment state="final"><source>Done</source><target>Fertig</target></segment>
+    </unit>
+  </file>
+</xliff>
+`,
+			pending: []string{"u1", "u1#1"},
+			want: `<xliff xmlns="urn:oasis:names:tc:xliff:document:2.0" version="2.0" srcLang="en" trgLang="de">
+  <file id="f1">
+    <unit id="u1">
+      <segment state="translated"><source>First</source><target>FIRST</target></segment>
+      <segment state="translated"><source>Second</source><target>SECOND</target></segment>
+    </unit>
+    <unit id="u2">
+      <notes><note>Not translated</note></notes>
+      <segment state="final"><source>Done</source><target>Fertig</target></segment>
+    </unit>
+  </file>
+</xliff>
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse(FormatXLIFF, []byte(tt.data), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var pending []string
+			for _, u := range Pending(c) {
+				pending = append(pending, u.Key)
+			}
+			if !slices.Equal(pending, tt.pending) {
+				t.Errorf("pending units = %q, want %q", pending, tt.pending)
+			}
+
+			if _, err := Translate(c, upper); err != nil {
+				t.Fatal(err)
+			}
+			data, err := c.Bytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Bytes() = %s\nwant %s", data, tt.want)
+			}
+		})
+	}
+}
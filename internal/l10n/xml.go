@@ -0,0 +1,157 @@
+package l10n
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+)
+
+// xmlToken is a raw XML token with its byte range in the document.
+type xmlToken struct {
+	tok        xml.Token
+	start, end int
+}
+
+// scanXML returns the raw tokens of an XML document with their byte ranges.
+func scanXML(data []byte) ([]xmlToken, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = true
+
+	var toks []xmlToken
+	for {
+		start := int(d.InputOffset())
+		tok, err := d.RawToken()
+		if errors.Is(err, io.EOF) {
+			return toks, nil
+		} else if err != nil {
+			return nil, err
+		}
+		toks = append(toks, xmlToken{
+			tok:   xml.CopyToken(tok),
+			start: start,
+			end:   int(d.InputOffset()),
+		})
+	}
+}
+
+// xmlElement is the byte range of an element and its content.
+type xmlElement struct {
+	name  xml.Name
+	attrs []xml.Attr
+
+	start, end           int
+	innerStart, innerEnd int
+}
+
+func (e xmlElement) attr(name string) (string, bool) {
+	for _, a := range e.attrs {
+		if a.Name.Local == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// element returns the element starting with the token at index i and the
+// index of its end token.
+func element(data []byte, toks []xmlToken, i int) (xmlElement, int) {
+	s := toks[i].tok.(xml.StartElement)
+	e := xmlElement{
+		name:       s.Name,
+		attrs:      s.Attr,
+		start:      toks[i].start,
+		innerStart: toks[i].end,
+	}
+
+	depth := 0
+	for j := i + 1; j < len(toks); j++ {
+		switch toks[j].tok.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			if depth > 0 {
+				depth--
+				continue
+			}
+			e.innerEnd = toks[j].start
+			e.end = toks[j].end
+			if e.innerEnd < e.innerStart {
+				// self-closing element
+				e.innerEnd = e.innerStart
+			}
+			return e, j
+		}
+	}
+	e.innerEnd, e.end = len(data), len(data)
+	return e, len(toks)
+}
+
+// startTag formats a start tag, replacing or adding the given attribute if
+// `name` is not empty.
+func startTag(e xmlElement, name string, value string) string {
+	var b strings.Builder
+	b.WriteString("<" + qualifiedName(e.name))
+	found := false
+	for _, a := range e.attrs {
+		v := a.Value
+		if name != "" && a.Name.Local == name && a.Name.Space == "" {
+			v, found = value, true
+		}
+		b.WriteString(" " + qualifiedName(a.Name) + `="` + escapeAttr(v) + `"`)
+	}
+	if name != "" && !found {
+		b.WriteString(" " + name + `="` + escapeAttr(value) + `"`)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+func qualifiedName(n xml.Name) string {
+	if n.Space != "" {
+		return n.Space + ":" + n.Local
+	}
+	return n.Local
+}
+
+var attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;")
+
+func escapeAttr(s string) string {
+	return attrEscaper.Replace(s)
+}
+
+// lineIndent returns the whitespace before position `pos` on its line and
+// whether there is nothing but whitespace.
+func lineIndent(data []byte, pos int) (string, bool) {
+	start := bytes.LastIndexByte(data[:pos], '\n') + 1
+	indent := data[start:pos]
+	if len(bytes.TrimLeft(indent, " \t")) > 0 {
+		return "", false
+	}
+	return string(indent), true
+}
+
+// edit replaces a byte range of a document.
+type edit struct {
+	start, end int
+	text       string
+}
+
+// splice applies non-overlapping edits to a document.
+func splice(data []byte, edits []edit) []byte {
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].start < edits[j].start
+	})
+
+	var b bytes.Buffer
+	pos := 0
+	for _, e := range edits {
+		b.Write(data[pos:e.start])
+		b.WriteString(e.text)
+		pos = e.end
+	}
+	b.Write(data[pos:])
+	return b.Bytes()
+}
@@ -0,0 +1,283 @@
+package markdown
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	urlPattern      = regexp.MustCompile(`^(?:https?|ftp)://[^\s<>]*[^\s<>.,;:!?'")\]]`)
+	autolinkPattern = regexp.MustCompile(`^<(?:[a-zA-Z][a-zA-Z0-9+.-]{1,31}:[^\s<>]*|[^\s@<>]+@[^\s@<>]+)>`)
+	htmlTagPattern  = regexp.MustCompile(`^<(?:/?[a-zA-Z][a-zA-Z0-9-]*(?:\s[^<>]*)?/?|!--.*?--)>`)
+)
+
+// inline converts inline Markdown to an XML fragment.
+//
+// Code spans, URLs, HTML tags, escapes and hard line breaks become empty `<x>`
+// elements. Emphasis, strikethrough, links and images become `<m>` elements
+// enclosing their text. Both refer to the marks of the segment by index.
+func (s *segment) inline(text string) string {
+	var b strings.Builder
+	plain := 0
+	flush := func(i int) {
+		b.WriteString(escapeXML(text[plain:i]))
+	}
+
+	for i := 0; i < len(text); {
+		rest := text[i:]
+
+		var (
+			n     int
+			inner string
+			m     mark
+		)
+		switch {
+		case rest[0] == '\\' && len(rest) > 1:
+			n, m = 2, mark{open: rest[:2], atomic: true}
+		case strings.HasPrefix(rest, "  \n"):
+			n, m = 3, mark{open: "  \n", atomic: true}
+		case rest[0] == '`':
+			n = codeSpan(rest)
+			m = mark{open: rest[:n], atomic: true}
+		case rest[0] == '<' && (autolinkPattern.MatchString(rest) || htmlTagPattern.MatchString(rest)):
+			n = len(autolinkPattern.FindString(rest))
+			if n == 0 {
+				n = len(htmlTagPattern.FindString(rest))
+			}
+			m = mark{open: rest[:n], atomic: true}
+		case urlPattern.MatchString(rest) && (i == 0 || !isWordByte(text[i-1])):
+			n = len(urlPattern.FindString(rest))
+			m = mark{open: rest[:n], atomic: true}
+		case rest[0] == '[' || strings.HasPrefix(rest, "!["):
+			n, inner, m = link(rest)
+		case rest[0] == '*' || rest[0] == '_' || strings.HasPrefix(rest, "~~"):
+			n, inner, m = emphasis(text, i)
+		}
+
+		if n == 0 {
+			i++
+			continue
+		}
+
+		flush(i)
+		id := len(s.marks)
+		s.marks = append(s.marks, m)
+		if m.atomic {
+			fmt.Fprintf(&b, `<x i="%d"/>`, id)
+		} else {
+			fmt.Fprintf(&b, `<m i="%d">%s</m>`, id, s.inline(inner))
+		}
+		i += n
+		plain = i
+	}
+	flush(len(text))
+	return b.String()
+}
+
+// codeSpan returns the length of the code span at the start of text, or the
+// length of the backtick run if it is not closed.
+func codeSpan(text string) int {
+	run := len(text) - len(strings.TrimLeft(text, "`"))
+	fence := text[:run]
+	for i := run; i < len(text); {
+		j := strings.Index(text[i:], fence)
+		if j < 0 {
+			break
+		}
+		j += i
+		end := j + run
+		if end == len(text) || text[end] != '`' {
+			return end
+		}
+		// skip longer backtick runs
+		for end < len(text) && text[end] == '`' {
+			end++
+		}
+		i = end
+	}
+	return run
+}
+
+// link parses an inline link, image or full reference link at the start of
+// text and returns its length, link text and mark.
+func link(text string) (int, string, mark) {
+	open := "["
+	if text[0] == '!' {
+		open = "!["
+	}
+
+	// find the closing bracket
+	depth := 0
+	end := -1
+	for i := len(open); i < len(text) && end < 0; i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '`':
+			i += codeSpan(text[i:]) - 1
+		case '[':
+			depth++
+		case ']':
+			if depth == 0 {
+				end = i
+			}
+			depth--
+		}
+	}
+	if end < 0 || end+1 >= len(text) {
+		return 0, "", mark{}
+	}
+
+	inner := text[len(open):end]
+	rest := text[end+1:]
+	var dest string
+	switch rest[0] {
+	case '(':
+		depth := 0
+		for i := 1; i < len(rest) && dest == ""; i++ {
+			switch rest[i] {
+			case '\\':
+				i++
+			case '(':
+				depth++
+			case ')':
+				if depth == 0 {
+					dest = rest[:i+1]
+				}
+				depth--
+			}
+		}
+	case '[':
+		if i := strings.IndexByte(rest, ']'); i > 0 {
+			dest = rest[:i+1]
+		}
+	}
+	if dest == "" || strings.TrimSpace(inner) == "" {
+		return 0, "", mark{}
+	}
+
+	n := end + 1 + len(dest)
+	return n, inner, mark{open: open, close: "]" + dest}
+}
+
+// emphasis parses emphasis or strikethrough at position i of text and
+// returns its length, enclosed text and mark.
+func emphasis(text string, i int) (int, string, mark) {
+	rest := text[i:]
+	c := rest[0]
+	run := len(rest) - len(strings.TrimLeft(rest, string(c)))
+	if run > 3 || (c == '~' && run != 2) {
+		return 0, "", mark{}
+	}
+	delim := rest[:run]
+
+	// the opening delimiter must be followed by a non-space and intraword
+	// underscores are not emphasis
+	if run == len(rest) || rest[run] == ' ' || rest[run] == '\n' {
+		return 0, "", mark{}
+	}
+	if c == '_' && i > 0 && isWordByte(text[i-1]) {
+		return 0, "", mark{}
+	}
+
+	for j := run; j < len(rest); j++ {
+		switch {
+		case rest[j] == '\\':
+			j++
+			continue
+		case rest[j] == '`':
+			j += codeSpan(rest[j:]) - 1
+			continue
+		}
+		if !strings.HasPrefix(rest[j:], delim) || rest[j-1] == ' ' {
+			continue
+		}
+		end := j + run
+		if end < len(rest) && rest[end] == c {
+			// part of a longer delimiter run
+			continue
+		}
+		if c == '_' && end < len(rest) && isWordByte(rest[end]) {
+			continue
+		}
+		return end, rest[run:j], mark{open: delim, close: delim}
+	}
+	return 0, "", mark{}
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || c >= 0x80
+}
+
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func escapeXML(s string) string {
+	return xmlEscaper.Replace(s)
+}
+
+// restore converts a translated XML fragment back to Markdown.
+func (s *segment) restore(text string) (string, error) {
+	d := xml.NewDecoder(strings.NewReader("<r>" + text + "</r>"))
+
+	var (
+		b     strings.Builder
+		stack []int
+		used  = make([]bool, len(s.marks))
+	)
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return "", fmt.Errorf("invalid translation: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.CharData:
+			b.Write(t)
+		case xml.StartElement:
+			if t.Name.Local == "r" {
+				continue
+			}
+			id, err := markID(t, len(s.marks))
+			if err != nil {
+				return "", err
+			}
+			used[id] = true
+			b.WriteString(s.marks[id].open)
+			stack = append(stack, id)
+		case xml.EndElement:
+			if t.Name.Local == "r" || len(stack) == 0 {
+				continue
+			}
+			id := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			b.WriteString(s.marks[id].close)
+		}
+	}
+
+	for id, ok := range used {
+		if !ok {
+			return "", fmt.Errorf("translation lost markup %q", s.marks[id].open)
+		}
+	}
+	return b.String(), nil
+}
+
+func markID(t xml.StartElement, n int) (int, error) {
+	for _, a := range t.Attr {
+		if a.Name.Local == "i" {
+			id, err := strconv.Atoi(a.Value)
+			if err != nil || id < 0 || id >= n {
+				break
+			}
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid markup element in translation: <%s>", t.Name.Local)
+}
@@ -0,0 +1,372 @@
+// Package markdown splits Markdown documents into prose segments that can be
+// translated with XML tag handling and reassembles the translated document.
+//
+// Front matter, code blocks, inline code, HTML, URLs and link reference
+// definitions are left untouched. Inline markup like emphasis and links is
+// converted to XML elements, so that it is kept around the translated words.
+package markdown
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Document is a parsed Markdown document.
+type Document struct {
+	parts []part
+}
+
+// part is either raw text, a prose segment or a nested document.
+type part struct {
+	raw string
+
+	segment *segment
+
+	// doc is a nested document whose lines are prefixed with `prefix`,
+	// e.g. a block quote.
+	doc    *Document
+	prefix string
+}
+
+type segment struct {
+	text  string
+	marks []mark
+	// cont is the prefix of continuation lines after hard line breaks.
+	cont string
+}
+
+// mark is a piece of markup replaced by an XML element. Atomic marks (code,
+// URLs, HTML) are replaced by empty elements, others enclose their text.
+type mark struct {
+	open, close string
+	atomic      bool
+}
+
+var (
+	fencePattern     = regexp.MustCompile("^\\s{0,3}(`{3,}|~{3,})")
+	headingPattern   = regexp.MustCompile(`^(\s{0,3}#{1,6}\s+)(.*?)(\s+#+)?\s*$`)
+	listPattern      = regexp.MustCompile(`^(\s*(?:[-*+]|\d{1,9}[.)])\s+(?:\[[ xX]\]\s+)?)(.*)$`)
+	quotePattern     = regexp.MustCompile(`^\s{0,3}>\s?`)
+	breakPattern     = regexp.MustCompile(`^\s{0,3}([-*_])(\s*[-*_]){2,}\s*$`)
+	linkDefPattern   = regexp.MustCompile(`^\s{0,3}\[[^\]]+\]:\s*\S`)
+	htmlBlockPattern = regexp.MustCompile(`^\s{0,3}<(/?[a-zA-Z][a-zA-Z0-9-]*[\s/>]|/?[a-zA-Z][a-zA-Z0-9-]*$|!--)`)
+	tableSepPattern  = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	markupPattern    = regexp.MustCompile(`<[^>]*>`)
+)
+
+// Parse parses a Markdown document.
+func Parse(text string) *Document {
+	lines := strings.Split(text, "\n")
+
+	d := &Document{}
+	i := 0
+
+	// front matter
+	if len(lines) > 0 && (lines[0] == "---" || lines[0] == "+++") {
+		for j := 1; j < len(lines); j++ {
+			if lines[j] == lines[0] {
+				d.raw(strings.Join(lines[:j+1], "\n"))
+				i = j + 1
+				break
+			}
+		}
+	}
+
+	d.parseBlocks(lines[i:], i > 0)
+	return d
+}
+
+// parseBlocks parses lines of block content. If `sep` is true, a newline is
+// added before the first line.
+func (d *Document) parseBlocks(lines []string, sep bool) {
+	inList := false
+	for i := 0; i < len(lines); {
+		if sep || i > 0 {
+			d.raw("\n")
+		}
+		line := lines[i]
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			d.raw(line)
+			i++
+			continue
+		case fencePattern.MatchString(line):
+			fence := strings.TrimSpace(fencePattern.FindStringSubmatch(line)[1])
+			j := i + 1
+			for j < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[j]), fence) {
+				j++
+			}
+			j = min(j+1, len(lines))
+			d.raw(strings.Join(lines[i:j], "\n"))
+			i = j
+			continue
+		case !inList && (strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")):
+			// indented code block
+			j := i + 1
+			for j < len(lines) && (strings.TrimSpace(lines[j]) == "" ||
+				strings.HasPrefix(lines[j], "    ") || strings.HasPrefix(lines[j], "\t")) {
+				j++
+			}
+			// trailing blank lines are not part of the code block
+			for j > i+1 && strings.TrimSpace(lines[j-1]) == "" {
+				j--
+			}
+			d.raw(strings.Join(lines[i:j], "\n"))
+			i = j
+			continue
+		case htmlBlockPattern.MatchString(line):
+			j := i + 1
+			for j < len(lines) && strings.TrimSpace(lines[j]) != "" {
+				j++
+			}
+			d.raw(strings.Join(lines[i:j], "\n"))
+			i = j
+			continue
+		case linkDefPattern.MatchString(line), breakPattern.MatchString(line):
+			d.raw(line)
+			i++
+			continue
+		case quotePattern.MatchString(line):
+			j := i
+			var inner []string
+			prefix := quotePattern.FindString(line)
+			for j < len(lines) && quotePattern.MatchString(lines[j]) {
+				inner = append(inner, strings.TrimPrefix(lines[j], quotePattern.FindString(lines[j])))
+				j++
+			}
+			nested := &Document{}
+			nested.parseBlocks(inner, false)
+			d.parts = append(d.parts, part{doc: nested, prefix: prefix})
+			i = j
+			continue
+		case i+1 < len(lines) && strings.Contains(line, "|") && tableSepPattern.MatchString(lines[i+1]):
+			d.tableRow(line)
+			d.raw("\n" + lines[i+1])
+			j := i + 2
+			for j < len(lines) && strings.Contains(lines[j], "|") && strings.TrimSpace(lines[j]) != "" {
+				d.raw("\n")
+				d.tableRow(lines[j])
+				j++
+			}
+			i = j
+			inList = false
+			continue
+		}
+
+		if m := headingPattern.FindStringSubmatch(line); m != nil {
+			d.raw(m[1])
+			d.text(m[2], "")
+			d.raw(m[3])
+			i++
+			inList = false
+			continue
+		}
+
+		// paragraphs and list items, including lazy continuation lines
+		prefix := ""
+		content := line
+		if m := listPattern.FindStringSubmatch(line); m != nil {
+			prefix, content = m[1], m[2]
+			inList = true
+		} else {
+			content = strings.TrimLeft(line, " \t")
+			prefix = line[:len(line)-len(content)]
+		}
+
+		j := i + 1
+		cont := ""
+		for j < len(lines) && !isBlockStart(lines, j) {
+			if j == i+1 {
+				trimmed := strings.TrimLeft(lines[j], " \t")
+				cont = lines[j][:len(lines[j])-len(trimmed)]
+			}
+			content += "\n" + strings.TrimLeft(lines[j], " \t")
+			j++
+		}
+
+		d.raw(prefix)
+		d.text(content, cont)
+		i = j
+	}
+}
+
+// isBlockStart reports whether line i ends a paragraph.
+func isBlockStart(lines []string, i int) bool {
+	line := lines[i]
+	return strings.TrimSpace(line) == "" ||
+		fencePattern.MatchString(line) ||
+		headingPattern.MatchString(line) ||
+		listPattern.MatchString(line) ||
+		quotePattern.MatchString(line) ||
+		breakPattern.MatchString(line) ||
+		htmlBlockPattern.MatchString(line) ||
+		(i+1 < len(lines) && strings.Contains(line, "|") && tableSepPattern.MatchString(lines[i+1]))
+}
+
+// tableRow adds the cells of a table row as separate segments.
+func (d *Document) tableRow(line string) {
+	cells := splitCells(line)
+	for i, cell := range cells {
+		if i > 0 {
+			d.raw("|")
+		}
+		trimmed := strings.TrimSpace(cell)
+		if trimmed == "" {
+			d.raw(cell)
+			continue
+		}
+		start := strings.Index(cell, trimmed)
+		d.raw(cell[:start])
+		d.text(trimmed, "")
+		d.raw(cell[start+len(trimmed):])
+	}
+}
+
+// splitCells splits a table row at pipes that are neither escaped nor in
+// inline code.
+func splitCells(line string) []string {
+	var cells []string
+	start := 0
+	inCode := false
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '`':
+			inCode = !inCode
+		case '|':
+			if !inCode {
+				cells = append(cells, line[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(cells, line[start:])
+}
+
+func (d *Document) raw(s string) {
+	if s == "" {
+		return
+	}
+	if n := len(d.parts); n > 0 && d.parts[n-1].segment == nil && d.parts[n-1].doc == nil {
+		d.parts[n-1].raw += s
+		return
+	}
+	d.parts = append(d.parts, part{raw: s})
+}
+
+// text adds a prose segment. Soft line breaks are replaced by spaces, hard
+// line breaks are kept.
+func (d *Document) text(s string, cont string) {
+	if strings.TrimSpace(s) == "" {
+		d.raw(s)
+		return
+	}
+
+	seg := &segment{cont: cont}
+	lines := strings.Split(s, "\n")
+	var b strings.Builder
+	for i, line := range lines {
+		if i == len(lines)-1 {
+			b.WriteString(line)
+			break
+		}
+		switch {
+		case strings.HasSuffix(line, "  "):
+			b.WriteString(strings.TrimRight(line, " "))
+			b.WriteString("  \n")
+		case strings.HasSuffix(line, "\\"):
+			b.WriteString(line)
+			b.WriteString("\n")
+		default:
+			b.WriteString(line)
+			b.WriteString(" ")
+		}
+	}
+	seg.text = seg.inline(b.String())
+	if strings.TrimSpace(markupPattern.ReplaceAllString(seg.text, "")) == "" {
+		// nothing to translate, e.g. only inline code
+		d.raw(s)
+		return
+	}
+	d.parts = append(d.parts, part{segment: seg})
+}
+
+// Segments returns the prose segments of the document as XML fragments.
+func (d *Document) Segments() []string {
+	var segments []string
+	for _, p := range d.parts {
+		switch {
+		case p.segment != nil:
+			segments = append(segments, p.segment.text)
+		case p.doc != nil:
+			segments = append(segments, p.doc.Segments()...)
+		}
+	}
+	return segments
+}
+
+// Render reassembles the document from the translations of its segments.
+func (d *Document) Render(translations []string) (string, error) {
+	var b strings.Builder
+	rest, err := d.render(&b, translations)
+	if err != nil {
+		return "", err
+	}
+	if len(rest) > 0 {
+		return "", fmt.Errorf("too many translations")
+	}
+	return b.String(), nil
+}
+
+func (d *Document) render(b *strings.Builder, translations []string) ([]string, error) {
+	for _, p := range d.parts {
+		switch {
+		case p.segment != nil:
+			if len(translations) == 0 {
+				return nil, fmt.Errorf("missing translations")
+			}
+			text, err := p.segment.restore(translations[0])
+			if err != nil {
+				return nil, err
+			}
+			translations = translations[1:]
+			b.WriteString(strings.ReplaceAll(text, "\n", "\n"+p.segment.cont))
+		case p.doc != nil:
+			var nested strings.Builder
+			var err error
+			translations, err = p.doc.render(&nested, translations)
+			if err != nil {
+				return nil, err
+			}
+			lines := strings.Split(nested.String(), "\n")
+			for i, line := range lines {
+				if i > 0 {
+					b.WriteString("\n")
+				}
+				if line == "" {
+					b.WriteString(strings.TrimRight(p.prefix, " "))
+				} else {
+					b.WriteString(p.prefix)
+				}
+				b.WriteString(line)
+			}
+		default:
+			b.WriteString(p.raw)
+		}
+	}
+	return translations, nil
+}
+
+// Translate translates the prose of a Markdown document. The translate
+// function must translate XML fragments, i.e. use XML tag handling.
+func Translate(text string, translate func(texts []string) ([]string, error)) (string, error) {
+	d := Parse(text)
+	translations, err := translate(d.Segments())
+	if err != nil {
+		return "", err
+	}
+	return d.Render(translations)
+}
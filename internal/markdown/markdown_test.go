@@ -0,0 +1,208 @@
+package markdown
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSegments(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "heading and inline markup",
+			text: "# Title\n\nHello *world*, see [docs](https://example.com).",
+			want: []string{"Title", `Hello <m i="0">world</m>, see <m i="1">docs</m>.`},
+		},
+		{
+			name: "front matter and inline code",
+			text: "---\ntitle: x\n---\nText with `code` here.",
+			want: []string{`Text with <x i="0"/> here.`},
+		},
+		{
+			name: "fenced code block",
+			text: "```go\nfmt.Println(1)\n```\n\nAfter code.",
+			want: []string{"After code."},
+		},
+		{
+			name: "indented code block",
+			text: "    indented code\n\nText",
+			want: []string{"Text"},
+		},
+		{
+			name: "block quote and lists",
+			text: "> quoted text\n> more\n\n- item one\n- [ ] task two",
+			want: []string{"quoted text more", "item one", "task two"},
+		},
+		{
+			name: "url and hard line break",
+			text: "Visit https://example.com/path now.\nSecond line  \nhard break",
+			want: []string{`Visit <x i="0"/> now. Second line<x i="1"/>hard break`},
+		},
+		{
+			name: "table",
+			text: "| a | b |\n|---|---|\n| one | two |",
+			want: []string{"a", "b", "one", "two"},
+		},
+		{
+			name: "html and escapes",
+			text: `A <span>tag</span> and \* escaped & more.`,
+			want: []string{`A <x i="0"/>tag<x i="1"/> and <x i="2"/> escaped &amp; more.`},
+		},
+		{
+			name: "emphasis, strikethrough and images",
+			text: "**bold** and ~~strike~~ and ![alt](img.png)",
+			want: []string{`<m i="0">bold</m> and <m i="1">strike</m> and <m i="2">alt</m>`},
+		},
+		{
+			name: "only code",
+			text: "```\ncode\n```",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.text).Segments()
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Segments() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderRoundTrip(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{
+			text: "# Title\n\nHello *world*, see [docs](https://example.com).",
+			want: "# Title\n\nHello *world*, see [docs](https://example.com).",
+		},
+		{
+			text: "---\ntitle: x\n---\nText with `code` here.",
+			want: "---\ntitle: x\n---\nText with `code` here.",
+		},
+		{
+			text: "```go\nfmt.Println(1)\n```\n\nAfter code.",
+			want: "```go\nfmt.Println(1)\n```\n\nAfter code.",
+		},
+		{
+			// soft line breaks are joined
+			text: "> quoted text\n> more\n\n- item one\n- [ ] task two",
+			want: "> quoted text more\n\n- item one\n- [ ] task two",
+		},
+		{
+			text: "Visit https://example.com/path now.\nSecond line  \nhard break",
+			want: "Visit https://example.com/path now. Second line  \nhard break",
+		},
+		{
+			text: "| a | b |\n|---|---|\n| one | two |",
+			want: "| a | b |\n|---|---|\n| one | two |",
+		},
+		{
+			text: `A <span>tag</span> and \* escaped & more.`,
+			want: `A <span>tag</span> and \* escaped & more.`,
+		},
+		{
+			text: "**bold** and ~~strike~~ and ![alt](img.png)\n",
+			want: "**bold** and ~~strike~~ and ![alt](img.png)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		d := Parse(tt.text)
+		got, err := d.Render(d.Segments())
+		if err != nil {
+			t.Errorf("Render(%q): %v", tt.text, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Render(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	d := Parse("Hello *world* and `code`.")
+
+	tests := []struct {
+		name         string
+		translations []string
+	}{
+		{"missing translation", nil},
+		{"too many translations", []string{`Hallo <m i="0">Welt</m> und <x i="1"/>.`, "extra"}},
+		{"lost markup", []string{`Hallo <m i="0">Welt</m> und Code.`}},
+		{"unknown element", []string{`Hallo <b>Welt</b> und <x i="1"/>.`}},
+		{"invalid id", []string{`Hallo <m i="7">Welt</m> und <x i="1"/>.`}},
+		{"invalid xml", []string{`Hallo <m i="0">Welt und <x i="1"/>.`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := d.Render(tt.translations); err == nil {
+				t.Errorf("Render(%q) = %q, want error", tt.translations, got)
+			}
+		})
+	}
+}
+
+// upper translates by upper-casing the text outside of XML tags and
+// entities.
+func upper(texts []string) ([]string, error) {
+	tagPattern := regexp.MustCompile(`<[^>]*>|&[a-z]+;|[^<&]+`)
+	translations := make([]string, len(texts))
+	for i, text := range texts {
+		translations[i] = tagPattern.ReplaceAllStringFunc(text, func(s string) string {
+			if strings.HasPrefix(s, "<") || strings.HasPrefix(s, "&") {
+				return s
+			}
+			return strings.ToUpper(s)
+		})
+	}
+	return translations, nil
+}
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{
+			text: "Hello *world*, see [docs](https://example.com).",
+			want: "HELLO *WORLD*, SEE [DOCS](https://example.com).",
+		},
+		{
+			text: "Run `go test` in https://example.com/repo first.",
+			want: "RUN `go test` IN https://example.com/repo FIRST.",
+		},
+		{
+			text: "---\ntitle: keep\n---\n\n## Usage\n\n```sh\nmake all\n```\n",
+			want: "---\ntitle: keep\n---\n\n## USAGE\n\n```sh\nmake all\n```\n",
+		},
+		{
+			text: "> a *quote*\n\n1. first\n2. second",
+			want: "> A *QUOTE*\n\n1. FIRST\n2. SECOND",
+		},
+		{
+			text: "Fish & chips <br> are \\*great\\*",
+			want: "FISH & CHIPS <br> ARE \\*GREAT\\*",
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := Translate(tt.text, upper)
+		if err != nil {
+			t.Errorf("Translate(%q): %v", tt.text, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Translate(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
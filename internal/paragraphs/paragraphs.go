@@ -0,0 +1,234 @@
+// Package paragraphs translates long texts paragraph by paragraph and caches
+// the translations, so that editing one paragraph only retranslates that
+// paragraph.
+package paragraphs
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// maxEntries is the number of cached translations above which the cache is
+// cleared.
+const maxEntries = 4096
+
+// separatorRegexp matches the whitespace between paragraphs, which contains
+// at least one blank line.
+var separatorRegexp = regexp.MustCompile(`[ \t\r]*\n[ \t\r]*\n\s*`)
+
+// TranslateFunc translates the given texts, returning one translation per
+// text.
+type TranslateFunc func(texts []string) ([]string, error)
+
+// Cache holds translated paragraphs. It is safe for concurrent use.
+type Cache struct {
+	// Context is the number of unchanged paragraphs before and after changed
+	// ones that are translated together with them, which gives the
+	// translation more context.
+	Context int
+
+	mu      sync.Mutex
+	entries map[string]string
+}
+
+// NewCache creates an empty cache that translates changed paragraphs with the
+// given number of context paragraphs.
+func NewCache(context int) *Cache {
+	return &Cache{
+		Context: context,
+		entries: make(map[string]string),
+	}
+}
+
+// Clear removes all cached translations, e.g. after a glossary changed.
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
+
+// Estimate returns the number of characters that are billed to translate the
+// text with the given settings, i.e. the length of the paragraphs that are not
+// cached and their context.
+func (c *Cache) Estimate(text string, settings string) int {
+	doc := split(text)
+
+	c.mu.Lock()
+	_, missing := c.lookup(doc.paragraphs, settings)
+	c.mu.Unlock()
+
+	var n int
+	for _, r := range c.runs(missing) {
+		n += utf8.RuneCountInString(strings.Join(doc.paragraphs[r.start:r.end], "\n\n"))
+	}
+	return n
+}
+
+// Translate translates the paragraphs of the text that are not cached for the
+// given settings and returns the translation of the whole text, keeping the
+// whitespace between the paragraphs. The settings identify everything that
+// affects the translation, e.g. the target language and the glossary.
+func (c *Cache) Translate(text string, settings string, translate TranslateFunc) (string, error) {
+	doc := split(text)
+
+	c.mu.Lock()
+	translations, missing := c.lookup(doc.paragraphs, settings)
+	c.mu.Unlock()
+
+	if err := c.translate(doc.paragraphs, missing, translate, translations); err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	if len(c.entries)+len(doc.paragraphs) > maxEntries {
+		clear(c.entries)
+	}
+	for i, p := range doc.paragraphs {
+		c.entries[key(settings, p)] = translations[i]
+	}
+	c.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString(doc.prefix)
+	for i, t := range translations {
+		b.WriteString(t)
+		b.WriteString(doc.separators[i])
+	}
+	return b.String(), nil
+}
+
+// translate requests the translations of the missing paragraphs. Consecutive
+// missing paragraphs are translated as one text together with their context.
+// If the translation of such a text cannot be split back into its
+// paragraphs, the missing paragraphs are translated on their own.
+func (c *Cache) translate(paragraphs []string, missing []bool, translate TranslateFunc, translations []string) error {
+	runs := c.runs(missing)
+	if len(runs) == 0 {
+		return nil
+	}
+
+	texts := make([]string, len(runs))
+	for i, r := range runs {
+		texts[i] = strings.Join(paragraphs[r.start:r.end], "\n\n")
+	}
+	results, err := translate(texts)
+	if err != nil {
+		return err
+	}
+
+	var single []int
+	for i, r := range runs {
+		var parts []string
+		if i < len(results) {
+			parts = separatorRegexp.Split(strings.TrimSpace(results[i]), -1)
+		}
+		for j := r.start; j < r.end; j++ {
+			if !missing[j] {
+				continue
+			}
+			if len(parts) == r.end-r.start {
+				translations[j] = parts[j-r.start]
+			} else {
+				single = append(single, j)
+			}
+		}
+	}
+	if len(single) == 0 {
+		return nil
+	}
+
+	texts = make([]string, len(single))
+	for i, j := range single {
+		texts[i] = paragraphs[j]
+	}
+	results, err = translate(texts)
+	if err != nil {
+		return err
+	}
+	for i, j := range single {
+		if i < len(results) {
+			translations[j] = results[i]
+		}
+	}
+	return nil
+}
+
+// lookup returns the cached translations of the paragraphs and reports for
+// every paragraph whether its translation is missing. The lock must be held.
+func (c *Cache) lookup(paragraphs []string, settings string) ([]string, []bool) {
+	translations := make([]string, len(paragraphs))
+	missing := make([]bool, len(paragraphs))
+	for i, p := range paragraphs {
+		t, ok := c.entries[key(settings, p)]
+		translations[i] = t
+		missing[i] = !ok
+	}
+	return translations, missing
+}
+
+// run is a range of paragraphs translated as one text.
+type run struct {
+	start, end int
+}
+
+// runs returns the ranges of missing paragraphs extended by the context
+// paragraphs. Overlapping ranges are merged.
+func (c *Cache) runs(missing []bool) []run {
+	var runs []run
+	for i, m := range missing {
+		if !m {
+			continue
+		}
+		start := max(i-c.Context, 0)
+		end := min(i+1+c.Context, len(missing))
+		if n := len(runs); n > 0 && start <= runs[n-1].end {
+			runs[n-1].end = end
+			continue
+		}
+		runs = append(runs, run{start, end})
+	}
+	return runs
+}
+
+// key returns the cache key of a paragraph.
+func key(settings string, paragraph string) string {
+	return settings + "\x00" + paragraph
+}
+
+// document is a text split into paragraphs.
+type document struct {
+	prefix     string   // leading whitespace
+	paragraphs []string // paragraphs without surrounding whitespace
+	separators []string // whitespace following each paragraph
+}
+
+// split splits the text into paragraphs separated by blank lines.
+func split(text string) document {
+	trimmed := strings.TrimLeft(text, " \t\r\n")
+	doc := document{prefix: text[:len(text)-len(trimmed)]}
+	text = trimmed
+	if text == "" {
+		return doc
+	}
+
+	for {
+		loc := separatorRegexp.FindStringIndex(text)
+		if loc == nil {
+			break
+		}
+		doc.paragraphs = append(doc.paragraphs, text[:loc[0]])
+		doc.separators = append(doc.separators, text[loc[0]:loc[1]])
+		text = text[loc[1]:]
+		if text == "" {
+			return doc
+		}
+	}
+
+	// trailing whitespace without a blank line
+	trimmed = strings.TrimRight(text, " \t\r\n")
+	doc.paragraphs = append(doc.paragraphs, trimmed)
+	doc.separators = append(doc.separators, text[len(trimmed):])
+	return doc
+}
@@ -0,0 +1,305 @@
+// Package rpc implements a line-delimited JSON-RPC 2.0 interface for editor
+// integrations. Every request and response is a single line of JSON.
+package rpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cluttrdev/deepl-go/deepl"
+
+	"github.com/DeepLcom/deepl-tui/internal/handlers"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeServerError    = -32000
+)
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *responseError  `json:"error,omitempty"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *responseError) Error() string {
+	return e.Message
+}
+
+// Server handles JSON-RPC requests.
+type Server struct {
+	translator *deepl.Translator
+	glossaries handlers.GlossariesHandler
+}
+
+// NewServer creates a new server using the given translator.
+func NewServer(translator *deepl.Translator) *Server {
+	return &Server{
+		translator: translator,
+	}
+}
+
+// Serve reads requests from `r` and writes responses to `w` until `r` is
+// exhausted. Requests are handled in order.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	write := enc.Encode
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil {
+			if err := write(errorResponse(nil, &responseError{Code: codeParseError, Message: err.Error()})); err != nil {
+				return err
+			}
+			continue
+		}
+
+		result, err := s.handle(req)
+		if req.ID == nil {
+			// notifications do not get a response
+			continue
+		}
+
+		res := response{JSONRPC: "2.0", ID: req.ID, Result: result}
+		if err != nil {
+			res = errorResponse(req.ID, err)
+		}
+		if err := write(res); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func errorResponse(id json.RawMessage, err error) response {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+
+	var rerr *responseError
+	if !errors.As(err, &rerr) {
+		rerr = &responseError{Code: codeServerError, Message: err.Error()}
+	}
+	return response{JSONRPC: "2.0", ID: id, Error: rerr}
+}
+
+func (s *Server) handle(req request) (any, error) {
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return nil, &responseError{Code: codeInvalidRequest, Message: "invalid request"}
+	}
+
+	switch req.Method {
+	case "translate":
+		return s.translate(req.Params)
+	case "detectLanguage":
+		return s.detectLanguage(req.Params)
+	case "glossaries.list":
+		return s.listGlossaries()
+	case "glossaries.lookup":
+		return s.lookupGlossaryTerm(req.Params)
+	case "usage":
+		return s.translator.GetUsage()
+	}
+	return nil, &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method not found: %s", req.Method)}
+}
+
+func decodeParams(raw json.RawMessage, v any) error {
+	if len(raw) == 0 {
+		return &responseError{Code: codeInvalidParams, Message: "missing params"}
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// texts is a list of texts that can also be given as a single string.
+type texts []string
+
+func (t *texts) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*t = texts{text}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*t = list
+	return nil
+}
+
+type translateParams struct {
+	Text       texts  `json:"text"`
+	SourceLang string `json:"source_lang"`
+	TargetLang string `json:"target_lang"`
+	Formality  string `json:"formality"`
+	GlossaryID string `json:"glossary_id"`
+}
+
+func (s *Server) translate(raw json.RawMessage) (any, error) {
+	var params translateParams
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	if len(params.Text) == 0 || params.TargetLang == "" {
+		return nil, &responseError{Code: codeInvalidParams, Message: "missing text or target_lang"}
+	}
+
+	if params.GlossaryID != "" && params.SourceLang == "" {
+		// required to determine the glossary source language
+		if _, ok := s.glossaries.Get(params.GlossaryID); !ok {
+			if err := s.glossaries.FetchGlossaries(s.translator); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	settings := handlers.TranslateSettings{
+		SourceLang: params.SourceLang,
+		TargetLang: params.TargetLang,
+		Formality:  params.Formality,
+		GlossaryID: params.GlossaryID,
+	}
+	translations, err := s.translator.TranslateText(params.Text, settings.TargetLang, settings.Options(&s.glossaries)...)
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		Translations []deepl.Translation `json:"translations"`
+	}{
+		Translations: translations,
+	}, nil
+}
+
+// detectLanguage detects the language of a text by translating it, since
+// there is no dedicated API endpoint. The translated characters are billed.
+func (s *Server) detectLanguage(raw json.RawMessage) (any, error) {
+	var params struct {
+		Text string `json:"text"`
+	}
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	if params.Text == "" {
+		return nil, &responseError{Code: codeInvalidParams, Message: "missing text"}
+	}
+
+	translations, err := s.translator.TranslateText([]string{params.Text}, "EN-US")
+	if err != nil {
+		return nil, err
+	}
+	if len(translations) == 0 {
+		return nil, errors.New("no translation returned")
+	}
+	return struct {
+		Language string `json:"language"`
+	}{
+		Language: translations[0].DetectedSourceLanguage,
+	}, nil
+}
+
+func (s *Server) listGlossaries() (any, error) {
+	if err := s.glossaries.FetchGlossaries(s.translator); err != nil {
+		return nil, err
+	}
+	return s.glossaries.List(), nil
+}
+
+type lookupParams struct {
+	Term       string `json:"term"`
+	GlossaryID string `json:"glossary_id"`
+	SourceLang string `json:"source_lang"`
+	TargetLang string `json:"target_lang"`
+}
+
+type lookupResult struct {
+	GlossaryID   string `json:"glossary_id"`
+	GlossaryName string `json:"glossary_name"`
+	Source       string `json:"source"`
+	Target       string `json:"target"`
+}
+
+// lookupGlossaryTerm searches glossary entries whose source contains the
+// given term. It searches either the given glossary or all glossaries
+// matching the given languages.
+func (s *Server) lookupGlossaryTerm(raw json.RawMessage) (any, error) {
+	var params lookupParams
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	if params.Term == "" {
+		return nil, &responseError{Code: codeInvalidParams, Message: "missing term"}
+	}
+	if params.GlossaryID == "" && params.TargetLang == "" {
+		return nil, &responseError{Code: codeInvalidParams, Message: "missing glossary_id or target_lang"}
+	}
+
+	if err := s.glossaries.FetchGlossaries(s.translator); err != nil {
+		return nil, err
+	}
+
+	var infos []deepl.GlossaryInfo
+	if params.GlossaryID != "" {
+		info, ok := s.glossaries.Get(params.GlossaryID)
+		if !ok {
+			return nil, &responseError{Code: codeInvalidParams, Message: "glossary not found"}
+		}
+		infos = append(infos, info)
+	} else {
+		infos = s.glossaries.Compatible(params.SourceLang, params.TargetLang)
+	}
+
+	term := strings.ToLower(params.Term)
+	results := []lookupResult{}
+	for _, info := range infos {
+		entries, ok := s.glossaries.Entries(info.GlossaryId)
+		if !ok {
+			var err error
+			entries, err = s.glossaries.FetchEntries(s.translator, info.GlossaryId)
+			if err != nil {
+				return nil, err
+			}
+		}
+		for _, e := range entries {
+			if strings.Contains(strings.ToLower(e.Source), term) {
+				results = append(results, lookupResult{
+					GlossaryID:   info.GlossaryId,
+					GlossaryName: info.Name,
+					Source:       e.Source,
+					Target:       e.Target,
+				})
+			}
+		}
+	}
+	return results, nil
+}
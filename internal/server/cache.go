@@ -0,0 +1,71 @@
+package server
+
+import (
+	"container/list"
+	"encoding/json"
+	"sync"
+
+	"github.com/cluttrdev/deepl-go/deepl"
+)
+
+// translationCache is a least recently used cache of translation results.
+type translationCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // of keys, most recently used first
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	elem         *list.Element
+	translations []deepl.Translation
+}
+
+func newTranslationCache(size int) *translationCache {
+	return &translationCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+func (c *translationCache) Get(req translateRequest) ([]deepl.Translation, bool) {
+	key := cacheKey(req)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e.elem)
+	return e.translations, true
+}
+
+func (c *translationCache) Put(req translateRequest, translations []deepl.Translation) {
+	key := cacheKey(req)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		c.order.MoveToFront(e.elem)
+		return
+	}
+
+	c.entries[key] = cacheEntry{
+		elem:         c.order.PushFront(key),
+		translations: translations,
+	}
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(string))
+	}
+}
+
+func cacheKey(req translateRequest) string {
+	b, _ := json.Marshal(req)
+	return string(b)
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// maxBodySize is the maximum size of a request body. It is large enough for
+// glossaries, which may have up to 10 MB of entries.
+const maxBodySize = 10 << 20
+
+// Options configures the access checks of the server.
+type Options struct {
+	// Addr is the address the server listens on. Only requests whose Host
+	// header names this port and either the listen host, `localhost` or an IP
+	// address are accepted, which prevents DNS rebinding attacks.
+	Addr string
+	// Token is the bearer token required in the Authorization header of
+	// every request. No token is required if empty.
+	Token string
+}
+
+// guard rejects requests that may come from a web page in the user's browser
+// rather than from a local tool, and limits the size of request bodies.
+func (s *Server) guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := s.checkHost(r.Host); err != nil {
+			writeError(w, http.StatusMisdirectedRequest, err)
+			return
+		}
+		if s.opts.Token != "" {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Token)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(w, http.StatusUnauthorized, errors.New("invalid or missing token"))
+				return
+			}
+		}
+		if r.Method == http.MethodPost {
+			// browsers send "simple" cross-origin requests without a
+			// preflight only with form or text content types
+			mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if mediaType != "application/json" {
+				writeError(w, http.StatusUnsupportedMediaType, errors.New("content type must be application/json"))
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// checkHost returns an error if the Host header of a request does not refer
+// to the address the server listens on.
+func (s *Server) checkHost(host string) error {
+	if s.opts.Addr == "" {
+		return nil
+	}
+	listenHost, listenPort, err := net.SplitHostPort(s.opts.Addr)
+	if err != nil {
+		return err
+	}
+
+	name, port, err := net.SplitHostPort(host)
+	if err != nil {
+		name, port = host, "80"
+	}
+	if port != listenPort {
+		return fmt.Errorf("invalid host: %s", host)
+	}
+	if strings.EqualFold(name, "localhost") || strings.EqualFold(name, listenHost) {
+		return nil
+	}
+	if net.ParseIP(strings.Trim(name, "[]")) != nil {
+		return nil
+	}
+	return fmt.Errorf("invalid host: %s", host)
+}
+
+// IsLoopback reports whether the address only accepts connections from the
+// local machine.
+func IsLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// decodeJSON decodes the request body into v. It writes an error response
+// and returns false if the body is invalid or too large.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == nil {
+		return true
+	}
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request body exceeds %d bytes", maxErr.Limit))
+		return false
+	}
+	writeError(w, http.StatusBadRequest, err)
+	return false
+}
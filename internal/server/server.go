@@ -0,0 +1,245 @@
+// Package server implements a local HTTP/JSON API for translating text and
+// managing glossaries, so that other tools do not need to know the DeepL
+// authentication key.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/cluttrdev/deepl-go/deepl"
+
+	"github.com/DeepLcom/deepl-tui/internal/api"
+	"github.com/DeepLcom/deepl-tui/internal/handlers"
+)
+
+// Server serves the local API.
+type Server struct {
+	translator *deepl.Translator
+	opts       Options
+
+	mu         sync.Mutex // guards glossaries
+	glossaries handlers.GlossariesHandler
+
+	cache *translationCache
+}
+
+// New creates a new server using the given translator.
+func New(translator *deepl.Translator, opts Options) *Server {
+	return &Server{
+		translator: translator,
+		opts:       opts,
+		cache:      newTranslationCache(1024),
+	}
+}
+
+// Handler returns the http handler serving the API endpoints.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/translate", s.handleTranslate)
+	mux.HandleFunc("GET /v1/languages", s.handleLanguages)
+	mux.HandleFunc("GET /v1/glossaries", s.handleListGlossaries)
+	mux.HandleFunc("GET /v1/glossaries/{id}", s.handleGetGlossary)
+	mux.HandleFunc("POST /v1/glossaries", s.handleCreateGlossary)
+	return s.guard(mux)
+}
+
+type translateRequest struct {
+	Text       []string `json:"text"`
+	SourceLang string   `json:"source_lang,omitempty"`
+	TargetLang string   `json:"target_lang"`
+	Formality  string   `json:"formality,omitempty"`
+	GlossaryID string   `json:"glossary_id,omitempty"`
+}
+
+type translateResponse struct {
+	Translations []deepl.Translation `json:"translations"`
+}
+
+func (s *Server) handleTranslate(w http.ResponseWriter, r *http.Request) {
+	var req translateRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if len(req.Text) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("missing text"))
+		return
+	}
+	if req.TargetLang == "" {
+		writeError(w, http.StatusBadRequest, errors.New("missing target_lang"))
+		return
+	}
+
+	if translations, ok := s.cache.Get(req); ok {
+		writeJSON(w, http.StatusOK, translateResponse{Translations: translations})
+		return
+	}
+
+	settings := handlers.TranslateSettings{
+		SourceLang: req.SourceLang,
+		TargetLang: req.TargetLang,
+		Formality:  req.Formality,
+		GlossaryID: req.GlossaryID,
+	}
+	s.mu.Lock()
+	if req.GlossaryID != "" && req.SourceLang == "" {
+		// required to determine the glossary source language
+		if _, ok := s.glossaries.Get(req.GlossaryID); !ok {
+			if err := s.glossaries.FetchGlossaries(s.translator); err != nil {
+				s.mu.Unlock()
+				writeUpstreamError(w, err)
+				return
+			}
+		}
+	}
+	opts := settings.Options(&s.glossaries)
+	s.mu.Unlock()
+
+	translations, err := s.translator.TranslateText(req.Text, req.TargetLang, opts...)
+	if err != nil {
+		writeUpstreamError(w, err)
+		return
+	}
+
+	s.cache.Put(req, translations)
+	writeJSON(w, http.StatusOK, translateResponse{Translations: translations})
+}
+
+func (s *Server) handleLanguages(w http.ResponseWriter, r *http.Request) {
+	langType := r.URL.Query().Get("type")
+	if langType == "" {
+		langType = "source"
+	}
+
+	langs, err := s.translator.GetLanguages(langType)
+	if err != nil {
+		writeUpstreamError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, langs)
+}
+
+type glossaryEntry struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+type glossary struct {
+	deepl.GlossaryInfo
+	Entries []glossaryEntry `json:"entries"`
+}
+
+func (s *Server) handleListGlossaries(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.glossaries.FetchGlossaries(s.translator); err != nil {
+		writeUpstreamError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.glossaries.List())
+}
+
+func (s *Server) handleGetGlossary(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	info, ok := s.glossaries.Get(id)
+	if !ok {
+		if err := s.glossaries.FetchGlossaries(s.translator); err != nil {
+			writeUpstreamError(w, err)
+			return
+		}
+		if info, ok = s.glossaries.Get(id); !ok {
+			writeError(w, http.StatusNotFound, errors.New("glossary not found"))
+			return
+		}
+	}
+
+	entries, ok := s.glossaries.Entries(id)
+	if !ok {
+		var err error
+		entries, err = s.glossaries.FetchEntries(s.translator, id)
+		if err != nil {
+			writeUpstreamError(w, err)
+			return
+		}
+	}
+
+	g := glossary{
+		GlossaryInfo: info,
+		Entries:      make([]glossaryEntry, 0, len(entries)),
+	}
+	for _, e := range entries {
+		g.Entries = append(g.Entries, glossaryEntry{Source: e.Source, Target: e.Target})
+	}
+	writeJSON(w, http.StatusOK, g)
+}
+
+type createGlossaryRequest struct {
+	Name       string          `json:"name"`
+	SourceLang string          `json:"source_lang"`
+	TargetLang string          `json:"target_lang"`
+	Entries    []glossaryEntry `json:"entries"`
+}
+
+func (s *Server) handleCreateGlossary(w http.ResponseWriter, r *http.Request) {
+	var req createGlossaryRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Name == "" || req.SourceLang == "" || req.TargetLang == "" {
+		writeError(w, http.StatusBadRequest, errors.New("missing name, source_lang or target_lang"))
+		return
+	}
+
+	entries := make([][2]string, 0, len(req.Entries))
+	for _, e := range req.Entries {
+		entries = append(entries, [2]string{e.Source, e.Target})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := s.glossaries.Create(s.translator, req.Name, req.SourceLang, req.TargetLang, entries)
+	if err != nil {
+		writeUpstreamError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, info)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeUpstreamError writes an error of a DeepL API request. Rate limit and
+// quota errors keep their status code, so that clients can back off.
+func writeUpstreamError(w http.ResponseWriter, err error) {
+	status := http.StatusBadGateway
+	var apiErr *api.Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Kind {
+		case api.ErrRateLimit:
+			status = http.StatusTooManyRequests
+		case api.ErrQuota:
+			status = apiErr.StatusCode
+		case api.ErrServer:
+			status = http.StatusServiceUnavailable
+		}
+	}
+	writeError(w, status, err)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, struct {
+		Error string `json:"error"`
+	}{
+		Error: err.Error(),
+	})
+}
@@ -0,0 +1,207 @@
+// Package subtitles reads, translates and writes SRT and WebVTT subtitles.
+//
+// Cue numbering, timing, settings and styling tags are preserved; only the
+// cue text is translated.
+package subtitles
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is a subtitle file format.
+type Format int
+
+const (
+	FormatSRT Format = iota
+	FormatVTT
+)
+
+// Cue is a single subtitle.
+type Cue struct {
+	// ID is the cue number of SRT files or the optional cue identifier of
+	// WebVTT files.
+	ID string
+	// Timing is the timing line including WebVTT cue settings.
+	Timing string
+	Start  time.Duration
+	End    time.Duration
+	Lines  []string
+}
+
+// File is a parsed subtitle file.
+type File struct {
+	Format Format
+	// blocks are the blocks of the file, either cues or raw blocks like the
+	// WebVTT header, comments and style blocks.
+	blocks []block
+	crlf   bool
+}
+
+type block struct {
+	raw string
+	cue *Cue
+}
+
+var timingPattern = regexp.MustCompile(`^\s*(\S+)\s+-->\s+(\S+)`)
+
+// ErrNoSubtitles is returned if the text does not contain subtitles.
+var ErrNoSubtitles = errors.New("no subtitles found")
+
+// IsFile reports whether the path has the extension of an SRT or WebVTT file.
+func IsFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".srt", ".vtt":
+		return true
+	}
+	return false
+}
+
+// Parse parses SRT or WebVTT subtitles. WebVTT is detected by its `WEBVTT`
+// header.
+func Parse(text string) (*File, error) {
+	f := &File{crlf: strings.Contains(text, "\r\n")}
+	text = strings.TrimPrefix(strings.ReplaceAll(text, "\r\n", "\n"), "\uFEFF")
+	if strings.HasPrefix(text, "WEBVTT") {
+		f.Format = FormatVTT
+	}
+
+	for i, raw := range splitBlocks(text) {
+		if i == 0 && f.Format == FormatVTT {
+			f.blocks = append(f.blocks, block{raw: raw})
+			continue
+		}
+
+		lines := strings.Split(raw, "\n")
+		timing := 0
+		if !timingPattern.MatchString(lines[0]) {
+			timing = 1
+		}
+		if timing >= len(lines) || !timingPattern.MatchString(lines[timing]) {
+			if f.Format == FormatSRT {
+				return nil, fmt.Errorf("invalid cue: %q", lines[0])
+			}
+			// NOTE, STYLE and REGION blocks
+			f.blocks = append(f.blocks, block{raw: raw})
+			continue
+		}
+
+		m := timingPattern.FindStringSubmatch(lines[timing])
+		start, err := parseTimestamp(m[1])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseTimestamp(m[2])
+		if err != nil {
+			return nil, err
+		}
+
+		cue := &Cue{
+			Timing: lines[timing],
+			Start:  start,
+			End:    end,
+			Lines:  lines[timing+1:],
+		}
+		if timing == 1 {
+			cue.ID = lines[0]
+		}
+		f.blocks = append(f.blocks, block{cue: cue})
+	}
+
+	if len(f.Cues()) == 0 {
+		return nil, ErrNoSubtitles
+	}
+	return f, nil
+}
+
+// splitBlocks splits text at blank lines.
+func splitBlocks(text string) []string {
+	var blocks []string
+	var current []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			if len(current) > 0 {
+				blocks = append(blocks, strings.Join(current, "\n"))
+				current = nil
+			}
+			continue
+		}
+		current = append(current, line)
+	}
+	if len(current) > 0 {
+		blocks = append(blocks, strings.Join(current, "\n"))
+	}
+	return blocks
+}
+
+// parseTimestamp parses `hh:mm:ss,mmm` (SRT) and `[hh:]mm:ss.mmm` (WebVTT)
+// timestamps.
+func parseTimestamp(s string) (time.Duration, error) {
+	s = strings.Replace(s, ",", ".", 1)
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid timestamp: %s", s)
+	}
+
+	var d time.Duration
+	for i, p := range parts {
+		unit := time.Minute
+		if len(parts) == 3 && i == 0 {
+			unit = time.Hour
+		}
+		if i == len(parts)-1 {
+			seconds, err := strconv.ParseFloat(p, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid timestamp: %s", s)
+			}
+			d += time.Duration(seconds * float64(time.Second))
+			break
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp: %s", s)
+		}
+		d += time.Duration(n) * unit
+	}
+	return d, nil
+}
+
+// Cues returns the cues of the file.
+func (f *File) Cues() []*Cue {
+	var cues []*Cue
+	for _, b := range f.blocks {
+		if b.cue != nil {
+			cues = append(cues, b.cue)
+		}
+	}
+	return cues
+}
+
+// String returns the subtitles in their original format.
+func (f *File) String() string {
+	blocks := make([]string, len(f.blocks))
+	for i, b := range f.blocks {
+		if b.cue == nil {
+			blocks[i] = b.raw
+			continue
+		}
+		var lines []string
+		if b.cue.ID != "" {
+			lines = append(lines, b.cue.ID)
+		}
+		lines = append(lines, b.cue.Timing)
+		lines = append(lines, b.cue.Lines...)
+		blocks[i] = strings.Join(lines, "\n")
+	}
+
+	text := strings.Join(blocks, "\n\n") + "\n"
+	if f.crlf {
+		text = strings.ReplaceAll(text, "\n", "\r\n")
+	}
+	return text
+}
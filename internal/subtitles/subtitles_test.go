@@ -0,0 +1,126 @@
+package subtitles
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		format Format
+		cues   []Cue
+	}{
+		{
+			name:   "srt",
+			text:   "1\n00:00:01,000 --> 00:00:02,500\nHello\nthere\n\n2\n00:01:00,000 --> 01:00:00,000\n<i>Bye</i>\n",
+			format: FormatSRT,
+			cues: []Cue{
+				{ID: "1", Timing: "00:00:01,000 --> 00:00:02,500", Start: time.Second, End: 2500 * time.Millisecond, Lines: []string{"Hello", "there"}},
+				{ID: "2", Timing: "00:01:00,000 --> 01:00:00,000", Start: time.Minute, End: time.Hour, Lines: []string{"<i>Bye</i>"}},
+			},
+		},
+		{
+			name:   "srt with crlf and bom",
+			text:   "\ufeff1\r\n00:00:01,000 --> 00:00:02,000\r\nHello\r\n",
+			format: FormatSRT,
+			cues: []Cue{
+				{ID: "1", Timing: "00:00:01,000 --> 00:00:02,000", Start: time.Second, End: 2 * time.Second, Lines: []string{"Hello"}},
+			},
+		},
+		{
+			name:   "webvtt",
+			text:   "WEBVTT - title\n\nNOTE a comment\n\nSTYLE\n::cue { color: red }\n\nintro\n00:01.000 --> 00:02.500 align:start\n<c.yellow>Hello</c>\n\n00:03.000 --> 00:04.000\nWorld\n",
+			format: FormatVTT,
+			cues: []Cue{
+				{ID: "intro", Timing: "00:01.000 --> 00:02.500 align:start", Start: time.Second, End: 2500 * time.Millisecond, Lines: []string{"<c.yellow>Hello</c>"}},
+				{Timing: "00:03.000 --> 00:04.000", Start: 3 * time.Second, End: 4 * time.Second, Lines: []string{"World"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(tt.text)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if f.Format != tt.format {
+				t.Errorf("Format = %v, want %v", f.Format, tt.format)
+			}
+			cues := f.Cues()
+			if len(cues) != len(tt.cues) {
+				t.Fatalf("got %d cues, want %d", len(cues), len(tt.cues))
+			}
+			for i, c := range cues {
+				want := tt.cues[i]
+				if c.ID != want.ID || c.Timing != want.Timing || c.Start != want.Start || c.End != want.End || !slices.Equal(c.Lines, want.Lines) {
+					t.Errorf("cue %d = %+v, want %+v", i, *c, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"plain text", "Hello world"},
+		{"srt without timing", "1\nnot a timing\ntext"},
+		{"invalid timestamp", "1\n00:xx:01,000 --> 00:00:02,000\ntext"},
+		{"webvtt without cues", "WEBVTT\n\nNOTE only a note\n"},
+		{"empty", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.text); err == nil {
+				t.Errorf("Parse(%q) succeeded, want error", tt.text)
+			}
+		})
+	}
+
+	if _, err := Parse("WEBVTT\n"); !errors.Is(err, ErrNoSubtitles) {
+		t.Errorf("Parse of an empty WebVTT file: got %v, want %v", err, ErrNoSubtitles)
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []string{
+		"1\n00:00:01,000 --> 00:00:02,000\nHello\nthere\n\n2\n00:00:03,000 --> 00:00:04,000\nBye\n",
+		"1\r\n00:00:01,000 --> 00:00:02,000\r\nHello\r\n",
+		"WEBVTT\n\nNOTE a comment\n\nid1\n00:01.000 --> 00:02.500 align:start\n<c.yellow>Hello</c>\n",
+	}
+
+	for _, text := range tests {
+		f, err := Parse(text)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", text, err)
+			continue
+		}
+		if got := f.String(); got != text {
+			t.Errorf("String() = %q, want %q", got, text)
+		}
+	}
+}
+
+func TestIsFile(t *testing.T) {
+	tests := map[string]bool{
+		"movie.srt":       true,
+		"movie.de.VTT":    true,
+		"notes.txt":       false,
+		"srt":             false,
+		"dir.srt/file.md": false,
+	}
+
+	for path, want := range tests {
+		if got := IsFile(path); got != want {
+			t.Errorf("IsFile(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
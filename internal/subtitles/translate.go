@@ -0,0 +1,374 @@
+package subtitles
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Options configures the translation of subtitles.
+type Options struct {
+	// Merge translates consecutive cues that continue a sentence together,
+	// which gives the translation more context. The translation is split back
+	// into the original cues.
+	Merge bool
+	// MaxGap is the maximum gap between merged cues.
+	MaxGap time.Duration
+	// MaxCues is the maximum number of cues merged.
+	MaxCues int
+}
+
+// DefaultOptions returns the default translate options.
+func DefaultOptions() Options {
+	return Options{
+		MaxGap:  time.Second,
+		MaxCues: 4,
+	}
+}
+
+// segment is a text to translate. It covers either one line of a cue, all
+// lines of a cue or several merged cues.
+type segment struct {
+	cues []*Cue
+	// line is the index of the translated line if only a single line of a
+	// cue is translated, -1 otherwise.
+	line  int
+	text  string
+	marks []string
+}
+
+// Translate translates the text of all cues. The translate function must
+// translate XML fragments, i.e. use XML tag handling.
+func Translate(f *File, translate func(texts []string) ([]string, error), opts Options) error {
+	segments := makeSegments(f.Cues(), opts)
+
+	texts := make([]string, len(segments))
+	for i, s := range segments {
+		texts[i] = s.text
+	}
+
+	translations, err := translate(texts)
+	if err != nil {
+		return err
+	}
+	if len(translations) != len(segments) {
+		return fmt.Errorf("expected %d translations, got %d", len(segments), len(translations))
+	}
+
+	for i, s := range segments {
+		text, err := s.restore(translations[i])
+		if err != nil {
+			return fmt.Errorf("cue %s: %w", s.cues[0].Timing, err)
+		}
+		s.apply(text)
+	}
+	return nil
+}
+
+// makeSegments groups the cues into segments.
+func makeSegments(cues []*Cue, opts Options) []*segment {
+	var segments []*segment
+	for i := 0; i < len(cues); i++ {
+		cue := cues[i]
+
+		if isDialogue(cue) {
+			// lines of different speakers are translated separately
+			for j, line := range cue.Lines {
+				s := &segment{cues: []*Cue{cue}, line: j}
+				s.text = s.protect(line)
+				segments = append(segments, s)
+			}
+			continue
+		}
+
+		s := &segment{cues: []*Cue{cue}, line: -1}
+		for opts.Merge && len(s.cues) < opts.MaxCues && i+1 < len(cues) &&
+			canMerge(s.cues[len(s.cues)-1], cues[i+1], opts.MaxGap) {
+			i++
+			s.cues = append(s.cues, cues[i])
+		}
+
+		var texts []string
+		for _, c := range s.cues {
+			texts = append(texts, strings.Join(c.Lines, " "))
+		}
+		s.text = s.protect(strings.Join(texts, " "))
+		segments = append(segments, s)
+	}
+	return segments
+}
+
+// isDialogue reports whether every line of a cue starts with a dash, i.e.
+// each line belongs to a different speaker.
+func isDialogue(cue *Cue) bool {
+	if len(cue.Lines) < 2 {
+		return false
+	}
+	for _, line := range cue.Lines {
+		if !strings.HasPrefix(strings.TrimSpace(line), "-") {
+			return false
+		}
+	}
+	return true
+}
+
+// canMerge reports whether cue `b` continues the sentence of cue `a`.
+// Cues with styling tags are not merged, since tags can not be split.
+func canMerge(a *Cue, b *Cue, maxGap time.Duration) bool {
+	if b.Start-a.End > maxGap || isDialogue(a) || isDialogue(b) {
+		return false
+	}
+	for _, c := range []*Cue{a, b} {
+		for _, line := range c.Lines {
+			if tagPattern.MatchString(line) {
+				return false
+			}
+		}
+	}
+	last := strings.TrimSpace(a.Lines[len(a.Lines)-1])
+	return last != "" && !strings.ContainsAny(last[len(last)-1:], ".!?:;\"'")
+}
+
+// tagPattern matches styling tags, e.g. `<i>`, `<font color="red">`,
+// `<c.yellow>`, `<v Speaker>`, WebVTT timestamps and ASS override tags like
+// `{\an8}`.
+var tagPattern = regexp.MustCompile(`<[^<>]+>|\{\\[^{}]*\}`)
+
+// protect converts cue text to an XML fragment. Tags with a matching closing
+// tag become `<m>` elements, other tags become empty `<x>` elements.
+func (s *segment) protect(text string) string {
+	locs := tagPattern.FindAllStringIndex(text, -1)
+	tags := make([]string, len(locs))
+	for i, loc := range locs {
+		tags[i] = text[loc[0]:loc[1]]
+	}
+
+	// find matching closing tags
+	closing := make([]int, len(tags))
+	for i := range closing {
+		closing[i] = -1
+	}
+	var stack []int
+	for i, tag := range tags {
+		name := tagName(tag)
+		switch {
+		case name == "":
+			continue
+		case strings.HasPrefix(tag, "</"):
+			for j := len(stack) - 1; j >= 0; j-- {
+				if tagName(tags[stack[j]]) == name {
+					closing[stack[j]] = i
+					stack = stack[:j]
+					break
+				}
+			}
+		default:
+			stack = append(stack, i)
+		}
+	}
+	isClosing := make([]bool, len(tags))
+	for _, j := range closing {
+		if j >= 0 {
+			isClosing[j] = true
+		}
+	}
+
+	var b strings.Builder
+	pos := 0
+	for i, loc := range locs {
+		b.WriteString(escapeXML(text[pos:loc[0]]))
+		pos = loc[1]
+		switch {
+		case isClosing[i]:
+			b.WriteString("</m>")
+		case closing[i] >= 0:
+			fmt.Fprintf(&b, `<m i="%d">`, len(s.marks))
+			s.marks = append(s.marks, tags[i]+"\x00"+tags[closing[i]])
+		default:
+			fmt.Fprintf(&b, `<x i="%d"/>`, len(s.marks))
+			s.marks = append(s.marks, tags[i])
+		}
+	}
+	b.WriteString(escapeXML(text[pos:]))
+	return b.String()
+}
+
+// tagName returns the name of an HTML-like tag, e.g. `c` for `<c.yellow>`.
+func tagName(tag string) string {
+	if !strings.HasPrefix(tag, "<") {
+		return ""
+	}
+	name := strings.TrimLeft(tag[1:], "/")
+	if i := strings.IndexAny(name, " .>"); i >= 0 {
+		name = name[:i]
+	}
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		// timestamps
+		return ""
+	}
+	return strings.ToLower(name)
+}
+
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func escapeXML(s string) string {
+	return xmlEscaper.Replace(s)
+}
+
+// restore converts a translated XML fragment back to cue text.
+func (s *segment) restore(text string) (string, error) {
+	d := xml.NewDecoder(strings.NewReader("<r>" + text + "</r>"))
+
+	var (
+		b     strings.Builder
+		stack []string
+	)
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return "", fmt.Errorf("invalid translation: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.CharData:
+			b.Write(t)
+		case xml.StartElement:
+			if t.Name.Local == "r" {
+				continue
+			}
+			mark, err := s.mark(t)
+			if err != nil {
+				return "", err
+			}
+			open, close, _ := strings.Cut(mark, "\x00")
+			b.WriteString(open)
+			stack = append(stack, close)
+		case xml.EndElement:
+			if t.Name.Local == "r" || len(stack) == 0 {
+				continue
+			}
+			b.WriteString(stack[len(stack)-1])
+			stack = stack[:len(stack)-1]
+		}
+	}
+	return b.String(), nil
+}
+
+func (s *segment) mark(t xml.StartElement) (string, error) {
+	for _, a := range t.Attr {
+		if a.Name.Local == "i" {
+			i, err := strconv.Atoi(a.Value)
+			if err == nil && i >= 0 && i < len(s.marks) {
+				return s.marks[i], nil
+			}
+		}
+	}
+	return "", fmt.Errorf("invalid markup in translation: <%s>", t.Name.Local)
+}
+
+// apply replaces the cue text by the translation, keeping the number of
+// lines of each cue.
+func (s *segment) apply(text string) {
+	if s.line >= 0 {
+		s.cues[0].Lines[s.line] = text
+		return
+	}
+
+	// split merged cues proportionally to their original length
+	parts := []string{text}
+	if len(s.cues) > 1 {
+		weights := make([]int, len(s.cues))
+		for i, c := range s.cues {
+			weights[i] = len(strings.Join(c.Lines, " "))
+		}
+		parts = splitWeighted(text, weights)
+	}
+
+	for i, c := range s.cues {
+		weights := make([]int, len(c.Lines))
+		for j, line := range c.Lines {
+			weights[j] = len(line)
+		}
+		// drop lines that are still empty, e.g. if the translation has
+		// fewer words than lines, since a blank line ends the cue
+		c.Lines = c.Lines[:0]
+		for _, line := range splitWeighted(parts[i], weights) {
+			if line != "" {
+				c.Lines = append(c.Lines, line)
+			}
+		}
+	}
+}
+
+// splitWeighted splits text at spaces outside of tags into len(weights)
+// parts whose lengths are roughly proportional to the weights.
+func splitWeighted(text string, weights []int) []string {
+	if len(weights) < 2 {
+		return []string{text}
+	}
+
+	total := 0
+	for _, w := range weights {
+		total += w
+	}
+
+	// candidate split positions
+	var spaces []int
+	depth := 0
+	for i, c := range text {
+		switch c {
+		case '<', '{':
+			depth++
+		case '>', '}':
+			depth--
+		case ' ':
+			if depth == 0 {
+				spaces = append(spaces, i)
+			}
+		}
+	}
+
+	parts := make([]string, 0, len(weights))
+	start, sum := 0, 0
+	for _, w := range weights[:len(weights)-1] {
+		sum += w
+		target := len(text) * sum / max(total, 1)
+		best := -1
+		for _, sp := range spaces {
+			if sp > start && (best < 0 || abs(sp-target) < abs(best-target)) {
+				best = sp
+			}
+		}
+		if best < 0 {
+			parts = append(parts, "")
+			continue
+		}
+		parts = append(parts, text[start:best])
+		start = best + 1
+	}
+	parts = append(parts, text[start:])
+
+	// avoid empty lines by moving a word from the previous part
+	for i := 1; i < len(parts); i++ {
+		if parts[i] == "" {
+			if j := strings.LastIndexByte(parts[i-1], ' '); j > 0 {
+				parts[i-1], parts[i] = parts[i-1][:j], parts[i-1][j+1:]
+			}
+		}
+	}
+	return parts
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
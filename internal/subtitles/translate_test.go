@@ -0,0 +1,207 @@
+package subtitles
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestProtectRestore(t *testing.T) {
+	tests := []struct {
+		text      string
+		protected string
+	}{
+		{
+			text:      "<i>Hello</i> there",
+			protected: `<m i="0">Hello</m> there`,
+		},
+		{
+			text:      `{\an8}Top & <b>bold <i>both</i></b>`,
+			protected: `<x i="0"/>Top &amp; <m i="1">bold <m i="2">both</m></m>`,
+		},
+		{
+			text:      "<v Bob>Hi <00:00:01.000>there",
+			protected: `<x i="0"/>Hi <x i="1"/>there`,
+		},
+		{
+			text:      "a </i> b",
+			protected: `a <x i="0"/> b`,
+		},
+		{
+			text:      "no tags",
+			protected: "no tags",
+		},
+	}
+
+	for _, tt := range tests {
+		s := &segment{}
+		protected := s.protect(tt.text)
+		if protected != tt.protected {
+			t.Errorf("protect(%q) = %q, want %q", tt.text, protected, tt.protected)
+			continue
+		}
+		restored, err := s.restore(protected)
+		if err != nil {
+			t.Errorf("restore(%q): %v", protected, err)
+			continue
+		}
+		if restored != tt.text {
+			t.Errorf("restore(%q) = %q, want %q", protected, restored, tt.text)
+		}
+	}
+}
+
+func TestRestoreTranslation(t *testing.T) {
+	s := &segment{}
+	s.protect("<i>Hello</i> & {\\an8}goodbye")
+
+	got, err := s.restore(`<x i="1"/>Au revoir &amp; <m i="0">bonjour</m>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\\an8}Au revoir & <i>bonjour</i>"; got != want {
+		t.Errorf("restore = %q, want %q", got, want)
+	}
+
+	for _, invalid := range []string{`<m i="5">x</m>`, `<b>x</b>`, `<m i="0">x`} {
+		if got, err := s.restore(invalid); err == nil {
+			t.Errorf("restore(%q) = %q, want error", invalid, got)
+		}
+	}
+}
+
+func TestSplitWeighted(t *testing.T) {
+	tests := []struct {
+		text    string
+		weights []int
+		want    []string
+	}{
+		{"one two three four five six", []int{10}, []string{"one two three four five six"}},
+		{"one two three four five six", []int{10, 10}, []string{"one two three", "four five six"}},
+		{"one two three four five six", []int{3, 20}, []string{"one", "two three four five six"}},
+		{"a b c", []int{1, 1, 1}, []string{"a", "b", "c"}},
+		{"<i>a b</i> c d", []int{5, 5}, []string{"<i>a", "b</i> c d"}},
+		{"{\\an8 x}word other", []int{1, 1}, []string{"{\\an8 x}word", "other"}},
+		// fewer words than lines
+		{"single", []int{3, 3}, []string{"", "single"}},
+	}
+
+	for _, tt := range tests {
+		got := splitWeighted(tt.text, tt.weights)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("splitWeighted(%q, %v) = %q, want %q", tt.text, tt.weights, got, tt.want)
+		}
+	}
+}
+
+// exclaim translates by appending an exclamation mark to every text.
+func exclaim(texts []string) ([]string, error) {
+	translations := make([]string, len(texts))
+	for i, text := range texts {
+		translations[i] = strings.ToUpper(text[:1]) + text[1:] + "!"
+	}
+	return translations, nil
+}
+
+func TestTranslate(t *testing.T) {
+	const text = "1\n00:00:01,000 --> 00:00:02,000\nthis sentence continues\n\n" +
+		"2\n00:00:02,100 --> 00:00:03,000\ninto the next cue.\n\n" +
+		"3\n00:00:05,000 --> 00:00:06,000\n- hi.\n- hello.\n\n" +
+		"4\n00:00:07,000 --> 00:00:08,000\n<i>styled</i>\ntwo lines\n"
+
+	tests := []struct {
+		name  string
+		merge bool
+		texts []string
+		want  string
+	}{
+		{
+			name: "cue by cue",
+			texts: []string{
+				"this sentence continues",
+				"into the next cue.",
+				"- hi.",
+				"- hello.",
+				`<m i="0">styled</m> two lines`,
+			},
+			want: "1\n00:00:01,000 --> 00:00:02,000\nThis sentence continues!\n\n" +
+				"2\n00:00:02,100 --> 00:00:03,000\nInto the next cue.!\n\n" +
+				"3\n00:00:05,000 --> 00:00:06,000\n- hi.!\n- hello.!\n\n" +
+				"4\n00:00:07,000 --> 00:00:08,000\n<i>styled</i>\ntwo lines!\n",
+		},
+		{
+			name:  "merged",
+			merge: true,
+			texts: []string{
+				"this sentence continues into the next cue.",
+				"- hi.",
+				"- hello.",
+				`<m i="0">styled</m> two lines`,
+			},
+			want: "1\n00:00:01,000 --> 00:00:02,000\nThis sentence continues\n\n" +
+				"2\n00:00:02,100 --> 00:00:03,000\ninto the next cue.!\n\n" +
+				"3\n00:00:05,000 --> 00:00:06,000\n- hi.!\n- hello.!\n\n" +
+				"4\n00:00:07,000 --> 00:00:08,000\n<i>styled</i>\ntwo lines!\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts := DefaultOptions()
+			opts.Merge = tt.merge
+
+			var texts []string
+			translate := func(t []string) ([]string, error) {
+				texts = t
+				return exclaim(t)
+			}
+			if err := Translate(f, translate, opts); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(texts, tt.texts) {
+				t.Errorf("translated texts = %q, want %q", texts, tt.texts)
+			}
+			if got := f.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslateDropsEmptyLines(t *testing.T) {
+	f, err := Parse("1\n00:00:01,000 --> 00:00:02,000\nTwo\nlines\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	translate := func(texts []string) ([]string, error) {
+		return []string{"Eine"}, nil
+	}
+	if err := Translate(f, translate, DefaultOptions()); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := f.String(), "1\n00:00:01,000 --> 00:00:02,000\nEine\n"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestTranslateErrors(t *testing.T) {
+	f, err := Parse("1\n00:00:01,000 --> 00:00:02,000\n<i>Hello</i>\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string][]string{
+		"missing translation": nil,
+		"invalid markup":      {`<m i="3">Hallo</m>`},
+	}
+	for name, translations := range tests {
+		translate := func([]string) ([]string, error) { return translations, nil }
+		if err := Translate(f, translate, DefaultOptions()); err == nil {
+			t.Errorf("%s: Translate succeeded, want error", name)
+		}
+	}
+}
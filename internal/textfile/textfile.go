@@ -0,0 +1,149 @@
+// Package textfile reads and writes text files with a given character
+// encoding and newline style.
+package textfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Newline styles.
+const (
+	NewlineUnix = "unix" // \n
+	NewlineDos  = "dos"  // \r\n
+	NewlineMac  = "mac"  // \r
+)
+
+// Format describes the character encoding and newline style of a text file.
+type Format struct {
+	// Encoding is the name of the character encoding, e.g. `utf-8`,
+	// `latin1` or `utf-16le`. An empty name means UTF-8.
+	Encoding string
+	// Newline is the newline style, one of `unix`, `dos` or `mac`. An empty
+	// style means `unix`.
+	Newline string
+}
+
+// ParseArgs parses command arguments of the form `[++enc=NAME] [++ff=STYLE]
+// [PATH]` and returns the path and format.
+func ParseArgs(args []string) (string, Format, error) {
+	var (
+		path   string
+		format Format
+	)
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "++enc="):
+			format.Encoding = strings.TrimPrefix(arg, "++enc=")
+			if _, err := lookupEncoding(format.Encoding); err != nil {
+				return "", format, err
+			}
+		case strings.HasPrefix(arg, "++ff="):
+			format.Newline = strings.TrimPrefix(arg, "++ff=")
+			switch format.Newline {
+			case NewlineUnix, NewlineDos, NewlineMac:
+			default:
+				return "", format, fmt.Errorf("invalid newline style: %s", format.Newline)
+			}
+		case path == "":
+			path = arg
+		default:
+			return "", format, errors.New("too many arguments")
+		}
+	}
+	return path, format, nil
+}
+
+// Read reads the text file at the given path using the given character
+// encoding. Newlines are converted to `\n` and the detected newline style is
+// returned as part of the format.
+func Read(path string, enc string) (string, Format, error) {
+	format := Format{Encoding: enc}
+
+	e, err := lookupEncoding(enc)
+	if err != nil {
+		return "", format, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", format, err
+	}
+
+	data, err = e.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", format, fmt.Errorf("error decoding %s: %w", path, err)
+	}
+	data = bytes.TrimPrefix(data, []byte("\uFEFF"))
+
+	text := string(data)
+	switch {
+	case strings.Contains(text, "\r\n"):
+		format.Newline = NewlineDos
+		text = strings.ReplaceAll(text, "\r\n", "\n")
+	case strings.Contains(text, "\r"):
+		format.Newline = NewlineMac
+		text = strings.ReplaceAll(text, "\r", "\n")
+	default:
+		format.Newline = NewlineUnix
+	}
+
+	return text, format, nil
+}
+
+// Write writes the text to the file at the given path using the given format.
+// An existing file is only replaced if `overwrite` is true.
+func Write(path string, text string, format Format, overwrite bool) (int, error) {
+	e, err := lookupEncoding(format.Encoding)
+	if err != nil {
+		return 0, err
+	}
+
+	switch format.Newline {
+	case NewlineDos:
+		text = strings.ReplaceAll(text, "\n", "\r\n")
+	case NewlineMac:
+		text = strings.ReplaceAll(text, "\n", "\r")
+	}
+
+	data, err := e.NewEncoder().Bytes([]byte(text))
+	if err != nil {
+		return 0, fmt.Errorf("error encoding text as %s: %w", format.Encoding, err)
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		flags |= os.O_EXCL
+	}
+	f, err := os.OpenFile(path, flags, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return 0, fmt.Errorf("%s exists, use `write!` to overwrite", path)
+	} else if err != nil {
+		return 0, err
+	}
+
+	n, err := f.Write(data)
+	if err != nil {
+		f.Close()
+		return n, err
+	}
+	return n, f.Close()
+}
+
+func lookupEncoding(name string) (encoding.Encoding, error) {
+	if name == "" {
+		return unicode.UTF8, nil
+	}
+	e, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown encoding: %s", name)
+	}
+	return e, nil
+}
@@ -0,0 +1,150 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/cluttrdev/deepl-go/deepl"
+)
+
+// AccountInfo is the information shown on the account page.
+type AccountInfo struct {
+	// Free reports whether the key is a DeepL API Free key.
+	Free bool
+	// Key is the masked authentication key.
+	Key string
+	// KeySource describes where the key was found.
+	KeySource string
+	// ServerURL is the API endpoint in use.
+	ServerURL string
+	// Warning is shown if the key does not match the endpoint.
+	Warning string
+
+	// Usage is nil while it is loading.
+	Usage *deepl.Usage
+	// UsageErr is the error requesting the usage.
+	UsageErr error
+
+	Glossaries   int
+	Multilingual bool
+}
+
+// AccountPage shows the account type, the API endpoint and the usage.
+type AccountPage struct {
+	tview.TextView
+
+	refresh func()
+}
+
+func newAccountPage() *AccountPage {
+	w := &AccountPage{
+		TextView: *tview.NewTextView(),
+	}
+	w.SetDynamicColors(true).
+		SetBorder(true).
+		SetTitle("Account").
+		SetBorderPadding(1, 1, 2, 2)
+
+	w.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyRune && event.Rune() == 'r' && w.refresh != nil {
+			w.refresh()
+			return nil
+		}
+		return event
+	})
+
+	return w
+}
+
+// SetRefreshFunc sets a handler that is called when the page is shown or the
+// user presses `r`, which should request the usage.
+func (w *AccountPage) SetRefreshFunc(refresh func()) *AccountPage {
+	w.refresh = refresh
+	return w
+}
+
+// SetInfo shows the account information.
+func (w *AccountPage) SetInfo(info AccountInfo) *AccountPage {
+	var b strings.Builder
+	row := func(label string, format string, args ...any) {
+		fmt.Fprintf(&b, "[::b]%-16s[::-]%s\n", label, tview.Escape(fmt.Sprintf(format, args...)))
+	}
+
+	keyType := "Pro"
+	if info.Free {
+		keyType = "Free"
+	}
+	row("Key type", "DeepL API %s", keyType)
+	row("Key", "%s (from %s)", info.Key, info.KeySource)
+	row("Endpoint", "%s", info.ServerURL)
+	if info.Warning != "" {
+		fmt.Fprintf(&b, "\n[yellow]%s[-]\n", tview.Escape(info.Warning))
+	}
+	b.WriteString("\n")
+
+	switch {
+	case info.UsageErr != nil:
+		row("Usage", "Error: %v", info.UsageErr)
+	case info.Usage == nil:
+		row("Usage", "Loading...")
+	default:
+		u := info.Usage
+		row("Characters", "%s", formatUsage(u.CharacterCount, u.CharacterLimit))
+		if u.DocumentLimit > 0 {
+			row("Documents", "%s", formatUsage(u.DocumentCount, u.DocumentLimit))
+		}
+		if u.TeamDocumentLimit > 0 {
+			row("Team documents", "%s", formatUsage(u.TeamDocumentCount, u.TeamDocumentLimit))
+		}
+	}
+
+	glossaries := formatCount(info.Glossaries)
+	if info.Multilingual {
+		glossaries += ", multilingual glossaries supported"
+	}
+	row("Glossaries", "%s", glossaries)
+
+	b.WriteString("\n")
+	if info.Free {
+		b.WriteString("The Free API allows translating up to 500,000 characters per month.\n" +
+			"Requests are rejected when the character limit is reached.\n")
+	} else {
+		b.WriteString("The Pro API bills every translated character. The character limit\n" +
+			"is the cost control limit set in your account, if any.\n")
+	}
+	b.WriteString("\n[::d]Press r to refresh.[::-]")
+
+	w.SetText(b.String())
+	return w
+}
+
+// formatUsage formats a usage counter with its limit and a bar.
+func formatUsage(count int, limit int) string {
+	if limit <= 0 {
+		return formatCount(count)
+	}
+
+	const width = 20
+	ratio := float64(count) / float64(limit)
+	filled := min(int(ratio*width+0.5), width)
+	return fmt.Sprintf("%s of %s (%.1f%%) [%s%s]",
+		formatCount(count), formatCount(limit), ratio*100,
+		strings.Repeat("#", filled), strings.Repeat(".", width-filled))
+}
+
+// formatCount formats a non-negative number with thousands separators.
+func formatCount(n int) string {
+	s := strconv.Itoa(n)
+	var b strings.Builder
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
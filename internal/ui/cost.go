@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+
+	"github.com/cluttrdev/deepl-go/deepl"
+)
+
+// newCostDialog creates the dialog asking to confirm the translation of a
+// large input.
+func newCostDialog() *tview.Modal {
+	return tview.NewModal().
+		AddButtons([]string{"Translate", "Cancel"})
+}
+
+// ConfirmTranslation asks the user to confirm the translation of a large input
+// of the given number of billed characters, showing the remaining quota.
+// The handler is called with the user's choice.
+func (ui *UI) ConfirmTranslation(characters int, usage *deepl.Usage, usageErr error, done func(bool)) {
+	page := ui.translatePage
+	page.costDialog.
+		SetText(costText(characters, usage, usageErr)).
+		SetFocus(0).
+		SetDoneFunc(func(index int, _ string) {
+			page.Pages.HidePage("cost")
+			ui.SetFocus(page.inputTextArea)
+			done(index == 0)
+		})
+	page.Pages.ShowPage("cost")
+	ui.SetFocus(page.costDialog)
+}
+
+// costText describes the cost of translating the given number of characters.
+func costText(characters int, usage *deepl.Usage, usageErr error) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Translate %s characters?\n\n", formatCount(characters))
+
+	switch {
+	case usageErr != nil:
+		fmt.Fprintf(&b, "The remaining quota is unknown: %v", usageErr)
+	case usage == nil:
+		b.WriteString("The remaining quota is unknown.")
+	case usage.CharacterLimit <= 0:
+		fmt.Fprintf(&b, "%s characters used in this billing period.", formatCount(usage.CharacterCount))
+	default:
+		remaining := max(usage.CharacterLimit-usage.CharacterCount, 0)
+		fmt.Fprintf(&b, "%s of %s characters left in this billing period.",
+			formatCount(remaining), formatCount(usage.CharacterLimit))
+		if characters > remaining {
+			b.WriteString("\n\nThis exceeds the remaining quota.")
+		}
+	}
+
+	return tview.Escape(b.String())
+}
@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/mattn/go-shellwords"
+)
+
+// editInput suspends the application and opens the input text in the user's
+// editor as given by $VISUAL or $EDITOR. When the editor exits, the edited
+// text replaces the input text.
+func (ui *UI) editInput() error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	args, err := shellwords.Parse(editor)
+	if err != nil {
+		return err
+	} else if len(args) == 0 {
+		return errors.New("invalid editor command")
+	}
+
+	f, err := os.CreateTemp("", "deepl-tui-*.txt")
+	if err != nil {
+		return err
+	}
+	path := f.Name()
+	defer os.Remove(path)
+
+	text := ui.translatePage.inputTextArea.GetText()
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	var runErr error
+	ui.Suspend(func() {
+		cmd := exec.Command(args[0], append(args[1:], path)...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		runErr = cmd.Run()
+	})
+	if runErr != nil {
+		return runErr
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	edited := string(data)
+	if !strings.HasSuffix(text, "\n") {
+		// most editors add a final newline
+		edited = strings.TrimSuffix(edited, "\n")
+	}
+	if edited != text {
+		// triggers the translation
+		ui.translatePage.inputTextArea.SetText(edited, false)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/DeepLcom/deepl-tui/internal/textfile"
+)
+
+// FilePicker lets the user browse directories and choose a file.
+type FilePicker struct {
+	*tview.Flex
+
+	dir       string
+	dirView   *tview.TextView
+	list      *tview.List
+	nameField *tview.InputField
+
+	done   func(path string)
+	cancel func()
+}
+
+func newFilePicker(ui *UI) *FilePicker {
+	w := &FilePicker{
+		Flex:      tview.NewFlex(),
+		dirView:   tview.NewTextView(),
+		list:      tview.NewList(),
+		nameField: tview.NewInputField(),
+	}
+
+	w.list.ShowSecondaryText(false)
+	w.nameField.
+		SetLabel("File: ").
+		SetDoneFunc(func(key tcell.Key) {
+			switch key {
+			case tcell.KeyEnter:
+				if name := w.nameField.GetText(); name != "" && w.done != nil {
+					if !filepath.IsAbs(name) {
+						name = filepath.Join(w.dir, name)
+					}
+					w.done(name)
+				}
+			case tcell.KeyEscape:
+				if w.cancel != nil {
+					w.cancel()
+				}
+			}
+		})
+	w.list.SetDoneFunc(func() {
+		if w.cancel != nil {
+			w.cancel()
+		}
+	})
+
+	w.Flex.SetDirection(tview.FlexRow).
+		AddItem(w.dirView, 1, 0, false).
+		AddItem(w.list, 0, 1, true).
+		AddItem(w.nameField, 1, 0, false)
+
+	w.Flex.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyTab, tcell.KeyBacktab:
+			// switch between file list and name field
+			if w.list.HasFocus() {
+				ui.SetFocus(w.nameField)
+			} else {
+				ui.SetFocus(w.list)
+			}
+			return nil
+		}
+		return event
+	})
+
+	return w
+}
+
+// SetDoneFunc sets the handler that is called with the chosen file path.
+func (w *FilePicker) SetDoneFunc(done func(string)) *FilePicker {
+	w.done = done
+	return w
+}
+
+// SetCancelFunc sets the handler that is called when the user cancels.
+func (w *FilePicker) SetCancelFunc(cancel func()) *FilePicker {
+	w.cancel = cancel
+	return w
+}
+
+// SetDir lists the contents of the given directory.
+func (w *FilePicker) SetDir(dir string) error {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		// directories first
+		return entries[i].IsDir() && !entries[j].IsDir()
+	})
+
+	w.dir = dir
+	w.dirView.SetText(dir)
+	w.list.Clear()
+	w.list.AddItem("../", "", 0, func() {
+		w.setDirOrFooter(filepath.Dir(dir))
+	})
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if e.IsDir() {
+			w.list.AddItem(e.Name()+"/", "", 0, func() {
+				w.setDirOrFooter(path)
+			})
+			continue
+		}
+		w.list.AddItem(e.Name(), "", 0, func() {
+			if w.done != nil {
+				w.done(path)
+			}
+		})
+	}
+	w.list.SetCurrentItem(0)
+	return nil
+}
+
+func (w *FilePicker) setDirOrFooter(dir string) {
+	if err := w.SetDir(dir); err != nil {
+		w.dirView.SetText(fmt.Sprintf("%s: %v", dir, err))
+	}
+}
+
+// openFile reads the file at the given path into the input text area.
+func (ui *UI) openFile(path string, format textfile.Format) error {
+	text, format, err := textfile.Read(path, format.Encoding)
+	if err != nil {
+		return err
+	}
+	ui.fileFormat = format
+	if ui.fileOpened != nil {
+		ui.fileOpened(path)
+	}
+
+	// triggers the translation
+	ui.translatePage.inputTextArea.SetText(text, false)
+	ui.SetFooter(fmt.Sprintf("Read %s", path))
+	return nil
+}
+
+// writeFile writes the output text to the file at the given path.
+// If the format does not specify an encoding or newline style, the one of the
+// last opened file is used.
+func (ui *UI) writeFile(path string, format textfile.Format, overwrite bool) error {
+	if format.Encoding == "" {
+		format.Encoding = ui.fileFormat.Encoding
+	}
+	if format.Newline == "" {
+		format.Newline = ui.fileFormat.Newline
+	}
+
+	n, err := textfile.Write(path, ui.translatePage.outputTextArea.GetText(), format, overwrite)
+	if err != nil {
+		return err
+	}
+	ui.SetFooter(fmt.Sprintf("Wrote %d bytes to %s", n, path))
+	return nil
+}
+
+// showFilePicker opens the file picker on the translate page. The chosen path
+// is passed to the given handler and the error it returns is shown in the
+// footer.
+func (ui *UI) showFilePicker(title string, handler func(path string) error) {
+	page := ui.translatePage
+	ui.switchToPage("translate")
+
+	hide := func() {
+		page.Pages.HidePage("files")
+		ui.SetFocus(page.inputTextArea)
+	}
+
+	page.filePicker.
+		SetDoneFunc(func(path string) {
+			hide()
+			if err := handler(path); err != nil {
+				ui.SetFooter(err.Error())
+			}
+		}).
+		SetCancelFunc(hide).
+		SetTitle(title)
+
+	if err := page.filePicker.SetDir("."); err != nil {
+		ui.SetFooter(err.Error())
+		return
+	}
+	page.filePicker.nameField.SetText("")
+	page.Pages.ShowPage("files")
+	ui.SetFocus(page.filePicker)
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/gdamore/tcell/v2"
 	"github.com/mattn/go-shellwords"
 	"github.com/rivo/tview"
+
+	"github.com/DeepLcom/deepl-tui/internal/textfile"
 )
 
 func (ui *UI) setupFooter() {
@@ -65,21 +67,76 @@ func (ui *UI) setupFooter() {
 
 		if len(args) < 1 {
 			return
-		} else if len(args) > 1 {
-			err = errors.New("invalid command")
-			return
 		}
 
-		switch args[0] {
-		case "translate", "glossaries":
-			ui.switchToPage(args[0])
-		case "size":
-			_, _, w, h := ui.translatePage.GetInnerRect()
-			err = errors.New(fmt.Sprintf("(%d, %d)", w, h))
-		default:
-			err = errors.New("invalid command")
-		}
+		err = ui.runCommand(args[0], args[1:])
 	})
 
 	ui.footer = cmdline
 }
+
+// runCommand runs a built-in command or one registered with
+// [UI.SetCommandFunc].
+func (ui *UI) runCommand(name string, args []string) error {
+	switch name {
+	case "translate", "glossaries", "account", "log":
+		if len(args) > 0 {
+			return errors.New("invalid command")
+		}
+		ui.switchToPage(name)
+		return nil
+	case "size":
+		if len(args) > 0 {
+			return errors.New("invalid command")
+		}
+		_, _, w, h := ui.translatePage.GetInnerRect()
+		return errors.New(fmt.Sprintf("(%d, %d)", w, h))
+	case "edit":
+		if len(args) > 0 {
+			return errors.New("invalid command")
+		}
+		ui.switchToPage("translate")
+		return ui.editInput()
+	case "open":
+		path, format, err := textfile.ParseArgs(args)
+		if err != nil {
+			return err
+		}
+		if path == "" {
+			ui.showFilePicker("Open", func(path string) error {
+				return ui.openFile(path, format)
+			})
+			return nil
+		}
+		ui.switchToPage("translate")
+		return ui.openFile(path, format)
+	case "write", "write!":
+		path, format, err := textfile.ParseArgs(args)
+		if err != nil {
+			return err
+		}
+		overwrite := name == "write!"
+		if path == "" {
+			ui.showFilePicker("Write", func(path string) error {
+				return ui.writeFile(path, format, overwrite)
+			})
+			return nil
+		}
+		return ui.writeFile(path, format, overwrite)
+	}
+
+	if handler, ok := ui.commands[name]; ok {
+		return handler(args)
+	}
+	return errors.New("invalid command")
+}
+
+// SetCommandFunc registers a command that can be run from the command prompt.
+// The handler receives the command arguments and its returned error is shown
+// in the footer.
+func (ui *UI) SetCommandFunc(name string, handler func(args []string) error) {
+	if ui.commands == nil {
+		ui.commands = make(map[string]func([]string) error)
+	}
+	ui.commands[name] = handler
+}
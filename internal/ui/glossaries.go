@@ -8,6 +8,8 @@ import (
 	"github.com/rivo/tview"
 
 	"github.com/cluttrdev/deepl-go/deepl"
+
+	"github.com/DeepLcom/deepl-tui/internal/deeplv3"
 )
 
 // GlossariesDialog is used to let the user choose a glossary displays the
@@ -17,6 +19,11 @@ type GlossariesDialog struct {
 
 	options [][2]string // list of id,name pairs
 
+	// stale is set if the option was selected without showing the entries of
+	// the glossary
+	stale     bool
+	selecting bool
+
 	dropDown *tview.DropDown
 	table    *tview.Table
 	buttons  *tview.Flex
@@ -87,6 +94,49 @@ func (w *GlossariesDialog) SetOptions(options [][2]string) *GlossariesDialog {
 	return w
 }
 
+// Current returns the id of the currently selected glossary or an empty
+// string if no glossary is selected.
+func (w *GlossariesDialog) Current() string {
+	index, _ := w.dropDown.GetCurrentOption()
+	if index > 0 {
+		return w.options[index-1][0]
+	}
+	return ""
+}
+
+// Options returns the list of glossary id,name pairs.
+func (w *GlossariesDialog) Options() [][2]string {
+	return w.options
+}
+
+// Select sets the current option to the glossary with the given id.
+// If there is no option with this id, no glossary is selected.
+// The entries of the glossary are not requested until the dialog is shown,
+// see Refresh.
+func (w *GlossariesDialog) Select(id string) *GlossariesDialog {
+	index := 0
+	for i, o := range w.options {
+		if o[0] == id {
+			index = i + 1
+			break
+		}
+	}
+	w.selecting = true
+	w.dropDown.SetCurrentOption(index)
+	w.selecting = false
+	return w
+}
+
+// Refresh shows the entries of the current option if it was selected with
+// Select.
+func (w *GlossariesDialog) Refresh() *GlossariesDialog {
+	if w.stale {
+		index, text := w.dropDown.GetCurrentOption()
+		w.selectedFunc(text, index)
+	}
+	return w
+}
+
 // SetDataFunc sets the handler that is used to get the glossary meta
 // information and entries to display when a glossary is selected.
 // The handler receives the id of the glossary.
@@ -112,7 +162,8 @@ func (w *GlossariesDialog) SetCancelFunc(cancel func()) *GlossariesDialog {
 
 func (w *GlossariesDialog) selectedFunc(text string, index int) {
 	w.table.Clear()
-	if index > 0 {
+	w.stale = w.selecting
+	if index > 0 && w.data != nil && !w.selecting {
 		id := w.options[index-1][0]
 		info, entries := w.data(id)
 		if info.GlossaryId != "" {
@@ -127,12 +178,103 @@ func (w *GlossariesDialog) selectedFunc(text string, index int) {
 	w.table.ScrollToBeginning()
 }
 
+// GlossaryEntryDialog is used to add a single entry to a glossary.
+type GlossaryEntryDialog struct {
+	*tview.Form
+
+	options [][2]string // list of id,name pairs
+
+	sourceItem   *tview.InputField
+	targetItem   *tview.InputField
+	glossaryItem *tview.DropDown
+
+	accepted func(id string, source string, target string)
+	cancel   func()
+}
+
+func newGlossaryEntryDialog() *GlossaryEntryDialog {
+	w := &GlossaryEntryDialog{
+		Form: tview.NewForm(),
+
+		sourceItem:   tview.NewInputField(),
+		targetItem:   tview.NewInputField(),
+		glossaryItem: tview.NewDropDown(),
+	}
+
+	w.sourceItem.SetLabel("Source")
+	w.targetItem.SetLabel("Target")
+	w.glossaryItem.SetLabel("Glossary")
+
+	w.Form.AddFormItem(w.sourceItem)
+	w.Form.AddFormItem(w.targetItem)
+	w.Form.AddFormItem(w.glossaryItem)
+	w.Form.
+		AddButton("Add", func() {
+			index, _ := w.glossaryItem.GetCurrentOption()
+			if w.accepted != nil && index >= 0 && index < len(w.options) {
+				w.accepted(w.options[index][0], w.sourceItem.GetText(), w.targetItem.GetText())
+			}
+		}).
+		AddButton("Cancel", func() {
+			if w.cancel != nil {
+				w.cancel()
+			}
+		}).
+		SetCancelFunc(func() {
+			if w.cancel != nil {
+				w.cancel()
+			}
+		})
+
+	return w
+}
+
+// SetEntry sets the glossary entry, the glossary options and the initially
+// selected glossary.
+func (w *GlossaryEntryDialog) SetEntry(source string, target string, options [][2]string, id string) *GlossaryEntryDialog {
+	w.sourceItem.SetText(source)
+	w.targetItem.SetText(target)
+
+	w.options = options
+	opts := make([]string, 0, len(options))
+	index := 0
+	for i, o := range options {
+		opts = append(opts, o[1])
+		if o[0] == id {
+			index = i
+		}
+	}
+	w.glossaryItem.SetOptions(opts, nil)
+	if len(opts) > 0 {
+		w.glossaryItem.SetCurrentOption(index)
+	}
+	w.Form.SetFocus(0)
+	return w
+}
+
+// SetAcceptedFunc sets the handler which is called when the user selects the
+// `add` button.
+// The handler receives the id of the selected glossary and the entry source
+// and target.
+func (w *GlossaryEntryDialog) SetAcceptedFunc(accepted func(string, string, string)) *GlossaryEntryDialog {
+	w.accepted = accepted
+	return w
+}
+
+// SetCancelFunc sets the handler which is called when the user selects the
+// `cancel` button.
+func (w *GlossaryEntryDialog) SetCancelFunc(cancel func()) *GlossaryEntryDialog {
+	w.cancel = cancel
+	return w
+}
+
 // GlossaryInfoForm displays glossary meta information in a form layout.
 type GlossaryInfoForm struct {
 	*tview.Form
 
 	nameItem         *tview.InputField
 	idItem           *tview.InputField
+	dictionaryItem   *tview.DropDown
 	sourceLangItem   *tview.DropDown
 	targetLangItem   *tview.DropDown
 	creationTimeItem *tview.InputField
@@ -145,6 +287,7 @@ func newGlossaryInfoForm() *GlossaryInfoForm {
 
 		nameItem:         tview.NewInputField(),
 		idItem:           tview.NewInputField(),
+		dictionaryItem:   tview.NewDropDown(),
 		sourceLangItem:   tview.NewDropDown(),
 		targetLangItem:   tview.NewDropDown(),
 		creationTimeItem: tview.NewInputField(),
@@ -153,6 +296,7 @@ func newGlossaryInfoForm() *GlossaryInfoForm {
 
 	w.nameItem.SetLabel("Name").SetFieldWidth(48)
 	w.idItem.SetLabel("ID").SetDisabled(true)
+	w.dictionaryItem.SetLabel("Dictionary")
 	w.sourceLangItem.SetLabel("Source Lang").SetDisabled(false)
 	w.targetLangItem.SetLabel("Target Lang").SetDisabled(false)
 	w.creationTimeItem.SetLabel("Creation Time").SetDisabled(true)
@@ -160,6 +304,7 @@ func newGlossaryInfoForm() *GlossaryInfoForm {
 
 	w.Form.AddFormItem(w.nameItem)
 	w.Form.AddFormItem(w.idItem)
+	w.Form.AddFormItem(w.dictionaryItem)
 	w.Form.AddFormItem(w.sourceLangItem)
 	w.Form.AddFormItem(w.targetLangItem)
 	w.Form.AddFormItem(w.creationTimeItem)
@@ -192,6 +337,19 @@ func (w *GlossaryInfoForm) SetInfo(info deepl.GlossaryInfo) *GlossaryInfoForm {
 	return w
 }
 
+// SetDictionary shows the language pair and entry count of a glossary
+// dictionary.
+func (w *GlossaryInfoForm) SetDictionary(dict deeplv3.Dictionary) *GlossaryInfoForm {
+	w.sourceLangItem.
+		SetTextOptions("", "", "", "", dict.SourceLang).
+		SetCurrentOption(-1)
+	w.targetLangItem.
+		SetTextOptions("", "", "", "", dict.TargetLang).
+		SetCurrentOption(-1)
+	w.entryCountItem.SetText(fmt.Sprintf("%d", dict.EntryCount))
+	return w
+}
+
 // GlossaryEntryForm is used to manage glossary entries.
 type GlossaryEntryForm struct {
 	*tview.Form
@@ -235,9 +393,16 @@ type GlossariesPage struct {
 
 	data func(id string) (deepl.GlossaryInfo, []deepl.GlossaryEntry)
 
-	create func(name string, source string, target string, entries [][2]string)
-	update func(id string, name string, entries [][2]string)
-	delete func(id string)
+	// multilingual glossary support
+	multilingual   bool
+	dictionaries   []deeplv3.Dictionary
+	dictionaryList func(id string) []deeplv3.Dictionary
+	dictionaryData func(id string, source string, target string) []deepl.GlossaryEntry
+
+	create           func(name string, source string, target string, entries [][2]string)
+	update           func(id string, name string, source string, target string, entries [][2]string)
+	delete           func(id string)
+	deleteDictionary func(id string, source string, target string)
 }
 
 func newGlossariesPage(ui *UI) *GlossariesPage {
@@ -266,6 +431,7 @@ func newGlossariesPage(ui *UI) *GlossariesPage {
 		AddButton("Create", w.onCreateGlossary).
 		AddButton("Update", w.onUpdateGlossary).
 		AddButton("Delete", w.onDeleteGlossary).
+		AddButton("Delete Dictionary", w.onDeleteDictionary).
 		SetHorizontal(false).
 		SetItemPadding(0).
 		SetTitle("Glossary Info").SetBorder(true)
@@ -305,7 +471,7 @@ func newGlossariesPage(ui *UI) *GlossariesPage {
 	entriesLayout.SetTitle("Glossary Entries").SetBorder(true)
 
 	rightLayout := tview.NewFlex().SetDirection(tview.FlexRow).
-		AddItem(w.infoForm, 12, 0, false).
+		AddItem(w.infoForm, 14, 0, false).
 		AddItem(entriesLayout, 0, 1, false)
 
 	layout := tview.NewFlex()
@@ -365,6 +531,30 @@ func (w *GlossariesPage) SetGlossaryDataFunc(data func(string) (deepl.GlossaryIn
 	return w
 }
 
+// SetMultilingual enables support for glossaries with multiple dictionaries.
+// If enabled, dictionaries can be added to and removed from existing
+// glossaries.
+func (w *GlossariesPage) SetMultilingual(enabled bool) *GlossariesPage {
+	w.multilingual = enabled
+	return w
+}
+
+// SetGlossaryDictionariesFunc sets the handler that is used to list the
+// dictionaries of a glossary. The handler receives the id of the glossary.
+func (w *GlossariesPage) SetGlossaryDictionariesFunc(list func(string) []deeplv3.Dictionary) *GlossariesPage {
+	w.dictionaryList = list
+	return w
+}
+
+// SetGlossaryDictionaryDataFunc sets the handler that is used to get the
+// entries of a glossary dictionary when it is selected.
+// The handler receives the id of the glossary and the source and target
+// language of the dictionary.
+func (w *GlossariesPage) SetGlossaryDictionaryDataFunc(data func(string, string, string) []deepl.GlossaryEntry) *GlossariesPage {
+	w.dictionaryData = data
+	return w
+}
+
 // SetGlossaryCreateFunc sets the handler that is called when the user selects the
 // glossary `create` button.
 // The handler receives the name, source lang, target lang and entries for the glossary.
@@ -374,8 +564,9 @@ func (w *GlossariesPage) SetGlossaryCreateFunc(create func(string, string, strin
 
 // SetGlossaryUpdateFunc sets the handler that is called when the user selects the
 // glossary `update` button.
-// The handler receives the id, name and entries of the glossary.
-func (w *GlossariesPage) SetGlossaryUpdateFunc(update func(string, string, [][2]string)) {
+// The handler receives the id, name, the languages of the selected dictionary
+// and its entries.
+func (w *GlossariesPage) SetGlossaryUpdateFunc(update func(string, string, string, string, [][2]string)) {
 	w.update = update
 }
 
@@ -386,6 +577,14 @@ func (w *GlossariesPage) SetGlossaryDeleteFunc(del func(string)) {
 	w.delete = del
 }
 
+// SetGlossaryDictionaryDeleteFunc sets the handler that is called when the
+// user selects the `delete dictionary` button.
+// The handler receives the id of the glossary and the languages of the
+// dictionary.
+func (w *GlossariesPage) SetGlossaryDictionaryDeleteFunc(del func(string, string, string)) {
+	w.deleteDictionary = del
+}
+
 func (w *GlossariesPage) registerKeyBindings(ui *UI) {
 	w.Flex.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		if event.Modifiers() == tcell.ModAlt {
@@ -416,22 +615,102 @@ func (w *GlossariesPage) selectedFunc(id string, index int) {
 	w.infoForm.GetButton(w.infoForm.GetButtonIndex("Create")).SetDisabled(!isIndex0)
 	w.infoForm.GetButton(w.infoForm.GetButtonIndex("Update")).SetDisabled(isIndex0)
 	w.infoForm.GetButton(w.infoForm.GetButtonIndex("Delete")).SetDisabled(isIndex0)
+	w.infoForm.GetButton(w.infoForm.GetButtonIndex("Delete Dictionary")).SetDisabled(true)
+	w.infoForm.dictionaryItem.SetOptions(nil, nil)
+	w.dictionaries = nil
 
 	w.table.Clear()
 	if w.data != nil {
 		info, entries := w.data(id)
 
 		w.infoForm.SetInfo(info)
-		for row, entry := range entries {
-			w.table.SetCell(row, 0, tview.NewTableCell(entry.Source).SetExpansion(1))
-			w.table.SetCell(row, 1, tview.NewTableCell(entry.Target).SetExpansion(1))
+		w.setTableEntries(entries)
+
+		if !isIndex0 && w.dictionaryData != nil {
+			w.setDictionaryOptions(info)
 		}
-		w.table.Select(w.table.GetRowCount(), 0) // `unselect`
 	}
-	w.table.ScrollToBeginning()
 	w.list.SetCurrentItem(index)
 }
 
+// setDictionaryOptions lists the dictionaries of the given glossary and
+// selects the first one.
+func (w *GlossariesPage) setDictionaryOptions(info deepl.GlossaryInfo) {
+	if dicts, ok := w.dictionaryOptions(info.GlossaryId); ok {
+		w.dictionaries = dicts
+	} else {
+		w.dictionaries = []deeplv3.Dictionary{{
+			SourceLang: info.SourceLang,
+			TargetLang: info.TargetLang,
+			EntryCount: info.EntryCount,
+		}}
+	}
+
+	opts := make([]string, 0, len(w.dictionaries)+1)
+	for _, d := range w.dictionaries {
+		opts = append(opts, fmt.Sprintf("%s → %s", strings.ToUpper(d.SourceLang), strings.ToUpper(d.TargetLang)))
+	}
+	if w.multilingual {
+		opts = append(opts, "New dictionary")
+	}
+
+	w.infoForm.dictionaryItem.
+		SetOptions(opts, func(_ string, index int) {
+			w.dictionarySelected(info.GlossaryId, index)
+		}).
+		SetCurrentOption(0)
+}
+
+func (w *GlossariesPage) dictionaryOptions(id string) ([]deeplv3.Dictionary, bool) {
+	if w.dictionaryList == nil {
+		return nil, false
+	}
+	dicts := w.dictionaryList(id)
+	return dicts, len(dicts) > 0
+}
+
+func (w *GlossariesPage) dictionarySelected(id string, index int) {
+	isNew := index >= len(w.dictionaries)
+	w.infoForm.sourceLangItem.SetDisabled(!isNew)
+	w.infoForm.targetLangItem.SetDisabled(!isNew)
+	w.infoForm.GetButton(w.infoForm.GetButtonIndex("Delete Dictionary")).
+		SetDisabled(isNew || !w.multilingual || len(w.dictionaries) < 2)
+
+	if isNew {
+		w.infoForm.sourceLangItem.SetTextOptions("", "", "", "", "").SetCurrentOption(0)
+		w.infoForm.targetLangItem.SetTextOptions("", "", "", "", "").SetCurrentOption(0)
+		w.infoForm.entryCountItem.SetText("0")
+		w.setTableEntries(nil)
+		return
+	}
+
+	dict := w.dictionaries[index]
+	w.infoForm.SetDictionary(dict)
+	w.setTableEntries(w.dictionaryData(id, dict.SourceLang, dict.TargetLang))
+}
+
+// selectedDictionary returns the languages of the selected dictionary or the
+// languages chosen for a new dictionary.
+func (w *GlossariesPage) selectedDictionary() (string, string) {
+	index, _ := w.infoForm.dictionaryItem.GetCurrentOption()
+	if index >= 0 && index < len(w.dictionaries) {
+		return w.dictionaries[index].SourceLang, w.dictionaries[index].TargetLang
+	}
+	_, source := w.infoForm.sourceLangItem.GetCurrentOption()
+	_, target := w.infoForm.targetLangItem.GetCurrentOption()
+	return source, target
+}
+
+func (w *GlossariesPage) setTableEntries(entries []deepl.GlossaryEntry) {
+	w.table.Clear()
+	for row, entry := range entries {
+		w.table.SetCell(row, 0, tview.NewTableCell(entry.Source).SetExpansion(1))
+		w.table.SetCell(row, 1, tview.NewTableCell(entry.Target).SetExpansion(1))
+	}
+	w.table.Select(w.table.GetRowCount(), 0) // `unselect`
+	w.table.ScrollToBeginning()
+}
+
 func (w *GlossariesPage) selectByName(name string) {
 	var index int = -1
 	for i := 0; i < w.list.GetItemCount(); i++ {
@@ -475,9 +754,10 @@ func (w *GlossariesPage) onUpdateGlossary() {
 	if w.update != nil {
 		name := w.infoForm.nameItem.GetText()
 		id := w.infoForm.idItem.GetText()
+		source, target := w.selectedDictionary()
 		entries := w.getTableEntries()
 
-		w.update(id, name, entries)
+		w.update(id, name, source, target, entries)
 		w.selectByName(name)
 	}
 }
@@ -490,6 +770,16 @@ func (w *GlossariesPage) onDeleteGlossary() {
 	}
 }
 
+func (w *GlossariesPage) onDeleteDictionary() {
+	if w.deleteDictionary != nil {
+		name := w.infoForm.nameItem.GetText()
+		id := w.infoForm.idItem.GetText()
+		source, target := w.selectedDictionary()
+		w.deleteDictionary(id, source, target)
+		w.selectByName(name)
+	}
+}
+
 func (w *GlossariesPage) onCreateEntry() {
 	source := w.entryForm.sourceItem.GetText()
 	target := w.entryForm.targetItem.GetText()
@@ -0,0 +1,110 @@
+package ui
+
+import (
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// highlightTextArea is a text area that highlights occurrences of a set of
+// terms in the visible text.
+//
+// Highlighting is done on the drawn screen content, so terms that are wrapped
+// across lines are not highlighted.
+type highlightTextArea struct {
+	*tview.TextArea
+
+	terms [][]rune
+}
+
+func newHighlightTextArea() *highlightTextArea {
+	return &highlightTextArea{
+		TextArea: tview.NewTextArea(),
+	}
+}
+
+// SetTerms replaces the terms to highlight. Matching is case-insensitive and
+// only considers whole words.
+func (t *highlightTextArea) SetTerms(terms []string) *highlightTextArea {
+	t.terms = t.terms[:0]
+	for _, term := range terms {
+		if term == "" {
+			continue
+		}
+		t.terms = append(t.terms, []rune(toLower(term)))
+	}
+	return t
+}
+
+// Draw draws the text area and highlights the terms.
+func (t *highlightTextArea) Draw(screen tcell.Screen) {
+	t.TextArea.Draw(screen)
+	if len(t.terms) == 0 {
+		return
+	}
+
+	x, y, width, height := t.GetInnerRect()
+	line := make([]rune, 0, width)
+	cols := make([]int, 0, width+1) // screen column of every rune in line
+	for row := y; row < y+height; row++ {
+		// wide runes, e.g. CJK characters, occupy two cells
+		line, cols = line[:0], cols[:0]
+		for col := 0; col < width; {
+			mainc, _, _, w := screen.GetContent(x+col, row)
+			line = append(line, unicode.ToLower(mainc))
+			cols = append(cols, col)
+			col += max(w, 1)
+		}
+		cols = append(cols, width)
+
+		for _, term := range t.terms {
+			for start := 0; start+len(term) <= len(line); start++ {
+				if !matchesAt(line, term, start) {
+					continue
+				}
+				for col := cols[start]; col < cols[start+len(term)]; col++ {
+					mainc, combc, style, _ := screen.GetContent(x+col, row)
+					style = style.Foreground(tview.Styles.TertiaryTextColor).Bold(true)
+					screen.SetContent(x+col, row, mainc, combc, style)
+				}
+				start += len(term) - 1
+			}
+		}
+	}
+}
+
+// matchesAt reports whether `term` occurs as a whole word in `line` at the
+// given position. Scripts written without spaces between words, e.g. Chinese
+// and Japanese, match anywhere.
+func matchesAt(line []rune, term []rune, pos int) bool {
+	for i, r := range term {
+		if line[pos+i] != r {
+			return false
+		}
+	}
+	if pos > 0 && isWordRune(line[pos-1]) && isWordRune(term[0]) {
+		return false
+	}
+	if end := pos + len(term); end < len(line) && isWordRune(line[end]) && isWordRune(term[len(term)-1]) {
+		return false
+	}
+	return true
+}
+
+// isWordRune reports whether the rune is part of a word in a script that
+// separates words with spaces.
+func isWordRune(r rune) bool {
+	if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Thai, unicode.Lao, unicode.Khmer, unicode.Myanmar) {
+		return false
+	}
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func toLower(s string) string {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return string(runes)
+}
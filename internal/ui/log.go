@@ -0,0 +1,208 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	// logTailSize is the number of bytes read from the end of the log file.
+	logTailSize = 256 * 1024
+	// logPollInterval is how often the log file is checked for new records
+	// while the log page is shown.
+	logPollInterval = time.Second
+)
+
+// LogPage shows the last records of the log file and follows new ones while
+// it is visible.
+type LogPage struct {
+	tview.TextView
+
+	app *tview.Application
+
+	path    string
+	visible atomic.Bool
+	size    atomic.Int64
+}
+
+func newLogPage(app *tview.Application) *LogPage {
+	w := &LogPage{
+		TextView: *tview.NewTextView(),
+		app:      app,
+	}
+	w.SetDynamicColors(true).
+		SetWrap(false).
+		SetBorder(true).
+		SetTitle("Log")
+
+	w.SetText("Logging is disabled, start with `--log-file <path>` to enable it.")
+
+	w.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyRune && event.Rune() == 'r' && w.path != "" {
+			w.reload()
+			return nil
+		}
+		return event
+	})
+
+	return w
+}
+
+// SetFile sets the path of the log file and starts following it.
+func (w *LogPage) SetFile(path string) *LogPage {
+	if path == "" || w.path != "" {
+		return w
+	}
+	w.path = path
+	w.SetTitle("Log: " + path)
+	w.SetText("")
+
+	go func() {
+		for range time.Tick(logPollInterval) {
+			if !w.visible.Load() {
+				continue
+			}
+			info, err := os.Stat(w.path)
+			if err != nil || info.Size() == w.size.Load() {
+				continue
+			}
+			w.reload()
+		}
+	}()
+
+	return w
+}
+
+// setVisible reports whether the page is shown and loads the log file when it
+// becomes visible.
+func (w *LogPage) setVisible(visible bool) {
+	if w.visible.Swap(visible) == visible || !visible || w.path == "" {
+		return
+	}
+	w.reload()
+}
+
+// reload reads the tail of the log file in the background and shows it.
+func (w *LogPage) reload() {
+	go func() {
+		text, size, err := readLogTail(w.path)
+		if err != nil {
+			text = fmt.Sprintf("[red]Error reading log file: %s[-]", tview.Escape(err.Error()))
+		}
+		w.size.Store(size)
+		w.app.QueueUpdateDraw(func() {
+			w.SetText(text)
+			w.ScrollToEnd()
+		})
+	}()
+}
+
+// readLogTail returns the formatted records at the end of the log file and
+// the size of the file.
+func readLogTail(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", 0, err
+	}
+	size := info.Size()
+
+	offset := max(size-logTailSize, 0)
+	data, err := io.ReadAll(io.NewSectionReader(f, offset, size-offset))
+	if err != nil {
+		return "", size, err
+	}
+	if offset > 0 {
+		// skip the partial first line
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+
+	var b strings.Builder
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		b.WriteString(formatLogRecord(line))
+		b.WriteByte('\n')
+	}
+	return b.String(), size, nil
+}
+
+// formatLogRecord formats a JSON log record as `15:04:05 LEVEL msg key=value`,
+// keeping the order of the attributes. Lines that are not JSON objects are
+// returned as they are.
+func formatLogRecord(line []byte) string {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return tview.Escape(string(line))
+	}
+
+	var (
+		ts, level, msg string
+		attrs          []string
+	)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return tview.Escape(string(line))
+		}
+		key, _ := tok.(string)
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return tview.Escape(string(line))
+		}
+		var s string
+		isString := json.Unmarshal(raw, &s) == nil
+
+		switch {
+		case key == "time" && isString:
+			ts = s
+			if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+				ts = t.Local().Format("15:04:05")
+			}
+		case key == "level" && isString:
+			level = s
+		case key == "msg" && isString:
+			msg = s
+		default:
+			value := string(raw)
+			if isString && !strings.ContainsAny(s, " =\"") && s != "" {
+				value = s
+			}
+			attrs = append(attrs, key+"="+value)
+		}
+	}
+
+	color := "white"
+	switch level {
+	case "DEBUG":
+		color = "gray"
+	case "WARN":
+		color = "yellow"
+	case "ERROR":
+		color = "red"
+	}
+
+	text := fmt.Sprintf("%s [%s]%-5s[-] %s", ts, color, level, tview.Escape(msg))
+	if len(attrs) > 0 {
+		text += " [gray]" + tview.Escape(strings.Join(attrs, " ")) + "[-]"
+	}
+	return text
+}
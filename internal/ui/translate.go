@@ -1,9 +1,12 @@
 package ui
 
 import (
-	"github.com/atotto/clipboard"
+	"strings"
+
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
+
+	"github.com/DeepLcom/deepl-tui/internal/textfile"
 )
 
 // TranslatePage provides widgets to translate input text and specify
@@ -17,13 +20,25 @@ type TranslatePage struct {
 	targetLangDropDown *tview.DropDown
 
 	formalityDropDown *tview.DropDown
+	optionsContainer  *tview.Flex
 	glossaryButton    *tview.Button
 	glossaryDialog    *GlossariesDialog
 	glossaryVisible   bool
 	glossarySelected  func(string)
 
-	inputTextArea  *tview.TextArea
-	outputTextArea *tview.TextArea
+	entryDialog *GlossaryEntryDialog
+	entryAdd    func(id string, source string, target string)
+
+	filePicker *FilePicker
+
+	modeButton  *tview.Button
+	modeToggled func()
+	translate   func()
+
+	costDialog *tview.Modal
+
+	inputTextArea  *highlightTextArea
+	outputTextArea *highlightTextArea
 }
 
 func newTranslatePage(ui *UI) *TranslatePage {
@@ -34,12 +49,12 @@ func newTranslatePage(ui *UI) *TranslatePage {
 
 	page.sourceLangDropDown = tview.NewDropDown()
 
-	page.inputTextArea = tview.NewTextArea().
-		SetPlaceholder("Type to translate.")
-	page.inputTextArea.SetClipboard(copyToClipboard, pasteFromClipboard)
+	page.inputTextArea = newHighlightTextArea()
+	page.inputTextArea.SetPlaceholder("Type to translate.")
+	page.inputTextArea.SetClipboard(ui.copyToClipboard, ui.pasteFromClipboard)
 
-	page.outputTextArea = tview.NewTextArea()
-	page.outputTextArea.SetClipboard(copyToClipboard, pasteFromClipboard)
+	page.outputTextArea = newHighlightTextArea()
+	page.outputTextArea.SetClipboard(ui.copyToClipboard, ui.pasteFromClipboard)
 	page.outputTextArea.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		if event.Key() == tcell.KeyCtrlQ { // copy to clipboard
 			return event
@@ -55,11 +70,19 @@ func newTranslatePage(ui *UI) *TranslatePage {
 		SetSelectedFunc(func() {
 			page.setGlossariesDialogVisibility(!page.glossaryVisible)
 		})
-	container := tview.NewFlex().SetDirection(tview.FlexColumn).
+	page.modeButton = tview.NewButton("Auto").
+		SetSelectedFunc(func() {
+			if page.modeToggled != nil {
+				page.modeToggled()
+			}
+		})
+	page.optionsContainer = tview.NewFlex().SetDirection(tview.FlexColumn).
 		AddItem(page.targetLangDropDown, 0, 1, true).
 		AddItem(page.formalityDropDown, 14, 0, true).
 		AddItem(nil, 1, 0, false).
 		AddItem(page.glossaryButton, 14, 0, false).
+		AddItem(nil, 1, 0, false).
+		AddItem(page.modeButton, 8, 0, false).
 		AddItem(nil, 1, 0, false)
 
 	page.layout.
@@ -67,7 +90,7 @@ func newTranslatePage(ui *UI) *TranslatePage {
 		SetColumns(0, 0).
 		SetBorders(true).
 		AddItem(page.sourceLangDropDown, 0, 0, 1, 1, 0, 0, false).
-		AddItem(container, 0, 1, 1, 1, 0, 0, false).
+		AddItem(page.optionsContainer, 0, 1, 1, 1, 0, 0, false).
 		AddItem(page.inputTextArea, 1, 0, 1, 1, 0, 0, true).
 		AddItem(page.outputTextArea, 1, 1, 1, 1, 0, 0, false)
 	page.layout.SetBorderPadding(0, 0, 0, 0)
@@ -86,9 +109,33 @@ func newTranslatePage(ui *UI) *TranslatePage {
 		SetTitle("Glossary").
 		SetBorder(true)
 
+	page.entryDialog = newGlossaryEntryDialog().
+		SetAcceptedFunc(func(id string, source string, target string) {
+			page.Pages.HidePage("entry")
+			ui.SetFocus(page.inputTextArea)
+			if page.entryAdd != nil && source != "" && target != "" {
+				page.entryAdd(id, source, target)
+			}
+		}).
+		SetCancelFunc(func() {
+			page.Pages.HidePage("entry")
+			ui.SetFocus(page.inputTextArea)
+		})
+	page.entryDialog.
+		SetTitle("Add Glossary Entry").
+		SetBorder(true)
+
+	page.filePicker = newFilePicker(ui)
+	page.filePicker.SetBorder(true)
+
+	page.costDialog = newCostDialog()
+
 	page.Pages.AddPage("main", page.layout, true, true)
 	page.Pages.AddPage("dialog", page.glossaryDialog, false, true)
 	page.Pages.HidePage("dialog")
+	page.Pages.AddPage("entry", centered(page.entryDialog, 64, 11), true, false)
+	page.Pages.AddPage("files", centered(page.filePicker, 72, 24), true, false)
+	page.Pages.AddPage("cost", page.costDialog, true, false)
 
 	page.registerKeyBindings(ui)
 
@@ -102,8 +149,70 @@ func (w *TranslatePage) SetGlossarySelectedFunc(selected func(string)) *Translat
 	return w
 }
 
+// SetActiveGlossary shows the name of the active glossary and highlights its
+// source terms in the input and its target terms in the output text.
+// An empty name resets the glossary button.
+func (w *TranslatePage) SetActiveGlossary(name string, sourceTerms []string, targetTerms []string) *TranslatePage {
+	label := "Glossary"
+	if name != "" {
+		label = "Glossary: " + name
+	}
+	width := tview.TaggedStringWidth(label) + 4
+	if width > 32 {
+		width = 32
+	}
+	w.glossaryButton.SetLabel(label)
+	w.optionsContainer.ResizeItem(w.glossaryButton, width, 0)
+
+	w.inputTextArea.SetTerms(sourceTerms)
+	w.outputTextArea.SetTerms(targetTerms)
+	return w
+}
+
+// SetGlossaryEntryAddFunc sets a handler that is called when the user adds an
+// entry to a glossary.
+// The handler receives the glossary id and the entry source and target.
+func (w *TranslatePage) SetGlossaryEntryAddFunc(add func(string, string, string)) *TranslatePage {
+	w.entryAdd = add
+	return w
+}
+
+// showGlossaryEntryDialog opens the dialog to add the current text selections
+// as a glossary entry.
+func (w *TranslatePage) showGlossaryEntryDialog(ui *UI) {
+	options := w.glossaryDialog.Options()
+	if len(options) == 0 {
+		ui.SetFooter("No glossary matches the selected languages")
+		return
+	}
+
+	source, _, _ := w.inputTextArea.GetSelection()
+	target, _, _ := w.outputTextArea.GetSelection()
+	w.entryDialog.SetEntry(
+		strings.TrimSpace(source),
+		strings.TrimSpace(target),
+		options,
+		w.glossaryDialog.Current(),
+	)
+	w.Pages.ShowPage("entry")
+	ui.SetFocus(w.entryDialog)
+}
+
+// SetManualMode shows whether the input is only translated on request.
+func (w *TranslatePage) SetManualMode(manual bool) *TranslatePage {
+	if manual {
+		w.modeButton.SetLabel("Manual")
+		w.inputTextArea.SetPlaceholder("Type and press alt-enter to translate.")
+	} else {
+		w.modeButton.SetLabel("Auto")
+		w.inputTextArea.SetPlaceholder("Type to translate.")
+	}
+	return w
+}
+
 func (w *TranslatePage) setGlossariesDialogVisibility(visible bool) {
 	if visible {
+		w.glossaryDialog.Refresh()
 		w.Pages.ShowPage("dialog")
 	} else {
 		w.Pages.HidePage("dialog")
@@ -114,6 +223,11 @@ func (w *TranslatePage) setGlossariesDialogVisibility(visible bool) {
 func (w *TranslatePage) registerKeyBindings(ui *UI) {
 	w.layout.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		switch key := event.Key(); key {
+		case tcell.KeyEnter:
+			if (event.Modifiers()&tcell.ModAlt) > 0 && w.translate != nil {
+				w.translate()
+				return nil
+			}
 		case tcell.KeyRune:
 			if (event.Modifiers() & tcell.ModAlt) > 0 {
 				switch event.Rune() {
@@ -132,6 +246,19 @@ func (w *TranslatePage) registerKeyBindings(ui *UI) {
 				case 'i':
 					ui.SetFocus(w.inputTextArea)
 					return nil
+				case 'a':
+					w.showGlossaryEntryDialog(ui)
+					return nil
+				case 'e':
+					if err := ui.editInput(); err != nil {
+						ui.SetFooter(err.Error())
+					}
+					return nil
+				case 'o':
+					ui.showFilePicker("Open", func(path string) error {
+						return ui.openFile(path, textfile.Format{})
+					})
+					return nil
 				}
 			}
 		}
@@ -166,17 +293,3 @@ func (w *TranslatePage) adjustToSize() {
 
 	w.glossaryDialog.SetRect(gbx+gbw-gww, gby, gww, gwh)
 }
-
-func copyToClipboard(text string) {
-	if err := clipboard.WriteAll(text); err != nil {
-		// what?
-	}
-}
-
-func pasteFromClipboard() string {
-	text, err := clipboard.ReadAll()
-	if err != nil {
-		// what?
-	}
-	return text
-}
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"strings"
 
@@ -9,6 +10,10 @@ import (
 	"github.com/rivo/tview"
 
 	"github.com/cluttrdev/deepl-go/deepl"
+
+	"github.com/DeepLcom/deepl-tui/internal/clipboard"
+	"github.com/DeepLcom/deepl-tui/internal/deeplv3"
+	"github.com/DeepLcom/deepl-tui/internal/textfile"
 )
 
 const (
@@ -64,31 +69,49 @@ type UI struct {
 	header *tview.TextView
 	footer *tview.InputField
 
+	commands map[string]func([]string) error
+
+	fileFormat textfile.Format // format of the last opened file
+	fileOpened func(path string)
+
+	clipboard clipboard.Backend
+
 	pages          *tview.Pages
 	pageIndex      []string
 	translatePage  *TranslatePage
 	glossariesPage *GlossariesPage
+	accountPage    *AccountPage
+	logPage        *LogPage
 }
 
 func NewUI() *UI {
 	ui := &UI{
 		Application: *tview.NewApplication(),
 	}
+	ui.clipboard, _ = clipboard.New("auto")
 
 	ui.header = tview.NewTextView().
 		SetTextAlign(tview.AlignLeft)
-	ui.header.SetBorder(true)
+	ui.header.
+		SetBorder(true).
+		SetTitleAlign(tview.AlignRight)
 
 	ui.setupFooter()
 
 	ui.translatePage = newTranslatePage(ui)
 	ui.glossariesPage = newGlossariesPage(ui)
+	ui.accountPage = newAccountPage()
+	ui.logPage = newLogPage(&ui.Application)
 
 	ui.pages = tview.NewPages()
 	ui.pages.AddPage("translate", ui.translatePage, true, true)
 	ui.pageIndex = append(ui.pageIndex, "translate")
 	ui.pages.AddPage("glossaries", ui.glossariesPage, true, false)
 	ui.pageIndex = append(ui.pageIndex, "glossaries")
+	ui.pages.AddPage("account", ui.accountPage, true, false)
+	ui.pageIndex = append(ui.pageIndex, "account")
+	ui.pages.AddPage("log", ui.logPage, true, false)
+	ui.pageIndex = append(ui.pageIndex, "log")
 
 	ui.layout = tview.NewGrid().
 		SetBorders(false).
@@ -139,6 +162,12 @@ func (ui *UI) registerKeybindings() {
 				ui.switchToCommandPrompt()
 				return nil
 			}
+			if event.Rune() == 'w' && (event.Modifiers()&tcell.ModAlt) > 0 {
+				if err := ui.runCommand("watch", nil); err != nil {
+					ui.SetFooter(err.Error())
+				}
+				return nil
+			}
 		}
 
 		return event
@@ -177,6 +206,11 @@ func (ui *UI) switchToPage(name string) {
 	ui.pages.SwitchToPage(name)
 	_, page := ui.pages.GetFrontPage()
 	ui.SetFocus(page)
+
+	if name == "account" && ui.accountPage.refresh != nil {
+		ui.accountPage.refresh()
+	}
+	ui.logPage.setVisible(name == "log")
 }
 
 func (ui *UI) cycePage() {
@@ -204,14 +238,84 @@ func (ui *UI) SetFooter(text string) {
 	ui.footer.SetText(text)
 }
 
+// SetStatus shows a short status text in the header border, e.g. that the
+// shown data is outdated. An empty text removes the status.
+func (ui *UI) SetStatus(text string) {
+	if text != "" {
+		text = " " + text + " "
+	}
+	ui.header.SetTitle(text)
+}
+
+// SetAccountInfo shows the account information on the account page.
+func (ui *UI) SetAccountInfo(info AccountInfo) {
+	ui.accountPage.SetInfo(info)
+}
+
+// SetAccountRefreshFunc sets a handler that is called when the account page
+// is shown or refreshed.
+func (ui *UI) SetAccountRefreshFunc(handler func()) {
+	ui.accountPage.SetRefreshFunc(handler)
+}
+
+// SetLogFile sets the path of the log file shown on the log page.
+func (ui *UI) SetLogFile(path string) {
+	ui.logPage.SetFile(path)
+}
+
+// SetClipboardBackend sets the clipboard used by the text areas.
+func (ui *UI) SetClipboardBackend(b clipboard.Backend) {
+	ui.clipboard = b
+}
+
+func (ui *UI) copyToClipboard(text string) {
+	if err := ui.clipboard.Copy(text); err != nil {
+		ui.SetFooter(fmt.Sprintf("Error copying to %s clipboard: %v", ui.clipboard.Name(), err))
+	}
+}
+
+func (ui *UI) pasteFromClipboard() string {
+	text, err := ui.clipboard.Paste()
+	if err != nil {
+		ui.SetFooter(fmt.Sprintf("Error pasting from %s clipboard: %v", ui.clipboard.Name(), err))
+	}
+	return text
+}
+
 func (ui *UI) SetSourceLangOptions(opts []string, selected func(string, int)) {
 	ui.translatePage.sourceLangDropDown.
 		SetOptions(opts, selected).
-		SetCurrentOption(0)
+		SetCurrentOption(0).
+		SetDisabled(false)
 }
 
 func (ui *UI) SetTargetLangOptions(opts []string, selected func(string, int)) {
-	ui.translatePage.targetLangDropDown.SetOptions(opts, selected)
+	// clear the selection, since the index refers to the old options
+	ui.translatePage.targetLangDropDown.
+		SetCurrentOption(-1).
+		SetOptions(opts, selected).
+		SetDisabled(false)
+}
+
+// SetLangOptionsPlaceholder disables the language drop-downs and shows the
+// given text in them until the options are set, e.g. while the languages are
+// loading.
+func (ui *UI) SetLangOptionsPlaceholder(text string) {
+	for _, d := range []*tview.DropDown{ui.translatePage.sourceLangDropDown, ui.translatePage.targetLangDropDown} {
+		d.SetOptions([]string{text}, nil).
+			SetCurrentOption(0).
+			SetDisabled(true)
+	}
+}
+
+// SelectSourceLang selects the source language option with the given index.
+func (ui *UI) SelectSourceLang(index int) {
+	ui.translatePage.sourceLangDropDown.SetCurrentOption(index)
+}
+
+// SelectTargetLang selects the target language option with the given index.
+func (ui *UI) SelectTargetLang(index int) {
+	ui.translatePage.targetLangDropDown.SetCurrentOption(index)
 }
 
 func (ui *UI) SetFormalityOptions(opts []string, selected func(string, int)) {
@@ -222,10 +326,21 @@ func (ui *UI) SetFormalityOptions(opts []string, selected func(string, int)) {
 
 // SetGlossaryOptions provides the ui with a list of available glossary ids and names.
 func (ui *UI) SetGlossaryOptions(options [][2]string) {
-	ui.translatePage.glossaryDialog.SetOptions(options)
 	ui.glossariesPage.SetOptions(options)
 }
 
+// SetGlossaryDialogOptions provides the translate page glossary dialog with a
+// list of glossary ids and names that can be selected.
+func (ui *UI) SetGlossaryDialogOptions(options [][2]string) {
+	ui.translatePage.glossaryDialog.SetOptions(options)
+}
+
+// SelectGlossary sets the glossary that is selected in the translate page
+// glossary dialog. An empty id clears the selection.
+func (ui *UI) SelectGlossary(id string) {
+	ui.translatePage.glossaryDialog.Select(id)
+}
+
 // SetGlossaryLanguageOptions provides the ui with a list of supported glossary languages.
 func (ui *UI) SetGlossaryLanguageOptions(langs []string) {
 	ui.glossariesPage.SetLanguageOptions(langs)
@@ -238,15 +353,35 @@ func (ui *UI) SetGlossaryDataFunc(handler func(string) (deepl.GlossaryInfo, []de
 	ui.glossariesPage.SetGlossaryDataFunc(handler)
 }
 
+// SetActiveGlossary shows the active glossary on the translate page and
+// highlights the source and target terms of its entries.
+// An empty name indicates that no glossary is active.
+func (ui *UI) SetActiveGlossary(name string, entries []deepl.GlossaryEntry) {
+	sourceTerms := make([]string, 0, len(entries))
+	targetTerms := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		sourceTerms = append(sourceTerms, entry.Source)
+		targetTerms = append(targetTerms, entry.Target)
+	}
+	ui.translatePage.SetActiveGlossary(name, sourceTerms, targetTerms)
+}
+
 func (ui *UI) SetGlossarySelectedFunc(handler func(string)) {
 	ui.translatePage.SetGlossarySelectedFunc(handler)
 }
 
+// SetGlossaryEntryAddFunc sets a handler that is called when the user adds a
+// glossary entry from the translate page.
+// It receives the glossary id and the entry source and target.
+func (ui *UI) SetGlossaryEntryAddFunc(handler func(string, string, string)) {
+	ui.translatePage.SetGlossaryEntryAddFunc(handler)
+}
+
 func (ui *UI) SetGlossaryCreateFunc(handler func(string, string, string, [][2]string)) {
 	ui.glossariesPage.SetGlossaryCreateFunc(handler)
 }
 
-func (ui *UI) SetGlossaryUpdateFunc(handler func(string, string, [][2]string)) {
+func (ui *UI) SetGlossaryUpdateFunc(handler func(string, string, string, string, [][2]string)) {
 	ui.glossariesPage.SetGlossaryUpdateFunc(handler)
 }
 
@@ -254,12 +389,64 @@ func (ui *UI) SetGlossaryDeleteFunc(handler func(string)) {
 	ui.glossariesPage.SetGlossaryDeleteFunc(handler)
 }
 
+// SetGlossaryMultilingual enables editing glossaries with multiple
+// dictionaries.
+func (ui *UI) SetGlossaryMultilingual(enabled bool) {
+	ui.glossariesPage.SetMultilingual(enabled)
+}
+
+// SetGlossaryDictionariesFunc sets a handler which is called to list the
+// dictionaries of a glossary. It receives the glossary ID as an argument.
+func (ui *UI) SetGlossaryDictionariesFunc(handler func(string) []deeplv3.Dictionary) {
+	ui.glossariesPage.SetGlossaryDictionariesFunc(handler)
+}
+
+// SetGlossaryDictionaryDataFunc sets a handler which is called to request the
+// entries of a glossary dictionary. It receives the glossary ID and the
+// dictionary source and target language as arguments.
+func (ui *UI) SetGlossaryDictionaryDataFunc(handler func(string, string, string) []deepl.GlossaryEntry) {
+	ui.glossariesPage.SetGlossaryDictionaryDataFunc(handler)
+}
+
+func (ui *UI) SetGlossaryDictionaryDeleteFunc(handler func(string, string, string)) {
+	ui.glossariesPage.SetGlossaryDictionaryDeleteFunc(handler)
+}
+
 // SetInputTextChangedFunc sets a handler that is called when the input text
 // changes.
 func (ui *UI) SetInputTextChangedFunc(handler func()) {
 	ui.translatePage.inputTextArea.SetChangedFunc(handler)
 }
 
+// SetManualMode shows whether the input is only translated on request.
+func (ui *UI) SetManualMode(manual bool) {
+	ui.translatePage.SetManualMode(manual)
+}
+
+// SetTranslateModeToggledFunc sets a handler that is called when the user
+// selects the translation mode button.
+func (ui *UI) SetTranslateModeToggledFunc(handler func()) {
+	ui.translatePage.modeToggled = handler
+}
+
+// SetTranslateFunc sets a handler that is called when the user requests a
+// translation with `alt-enter`.
+func (ui *UI) SetTranslateFunc(handler func()) {
+	ui.translatePage.translate = handler
+}
+
+// SetFileOpenedFunc sets a handler which is called when a file is read into
+// the input text area, before it is translated.
+// It receives the path of the file as an argument.
+func (ui *UI) SetFileOpenedFunc(handler func(string)) {
+	ui.fileOpened = handler
+}
+
+// SetInputText replaces the input text.
+func (ui *UI) SetInputText(text string) {
+	ui.translatePage.inputTextArea.SetText(text, false)
+}
+
 func (ui *UI) GetInputText() string {
 	return ui.translatePage.inputTextArea.GetText()
 }
@@ -274,6 +461,10 @@ func (ui *UI) WriteOutputText(r io.Reader) error {
 	return nil
 }
 
+func (ui *UI) GetOutputText() string {
+	return ui.translatePage.outputTextArea.GetText()
+}
+
 func (ui *UI) ClearOutputText() {
 	ui.translatePage.outputTextArea.SetText("", false)
 }
@@ -298,3 +489,12 @@ func modal(p tview.Primitive, x, y, width, height int, focus bool) tview.Primiti
 
 	return m
 }
+
+// Returns a new primitive which puts the provided one at the center of the
+// available space and sets its size to the given width and height.
+func centered(p tview.Primitive, width, height int) tview.Primitive {
+	return tview.NewGrid().
+		SetColumns(0, width, 0).
+		SetRows(0, height, 0).
+		AddItem(p, 1, 1, 1, 1, 0, 0, true)
+}
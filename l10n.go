@@ -0,0 +1,144 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cluttrdev/deepl-go/deepl"
+
+	"github.com/DeepLcom/deepl-tui/internal/handlers"
+	"github.com/DeepLcom/deepl-tui/internal/l10n"
+)
+
+// runL10n translates the untranslated entries of a localization catalog.
+func runL10n(translator *deepl.Translator, args []string) error {
+	const usage = "usage: deepl-tui l10n --to LANG [--from LANG] [--glossary NAME] [--out PATH] FILE"
+
+	flags := flag.NewFlagSet("l10n", flag.ContinueOnError)
+	to := flags.String("to", "", "the target language.")
+	from := flags.String("from", "", "the source language, detected if not set.")
+	formality := flags.String("formality", "", "the formality of the translations.")
+	glossary := flags.String("glossary", "", "the name or id of the glossary to use.")
+	out := flags.String("out", "", "the output file (default: the input file for PO and XLIFF, LANG.po for PO templates, the conventional location of the target language otherwise).")
+	dryRun := flags.Bool("dry-run", false, "only show the entries that need to be translated.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *to == "" || flags.NArg() != 1 {
+		return errors.New(usage)
+	}
+
+	path := flags.Arg(0)
+	format, err := l10n.DetectFormat(path)
+	if err != nil {
+		return err
+	}
+
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	template := l10n.IsTemplate(path)
+	outPath := *out
+	if outPath == "" {
+		outPath = path
+		if !format.Bilingual() || template {
+			outPath = l10n.TargetPath(format, path, *to)
+		}
+	}
+	if template {
+		if sameFile(outPath, path) {
+			return fmt.Errorf("refusing to overwrite the template %s, choose another --out file", path)
+		}
+		if _, err := os.Stat(outPath); err == nil {
+			return fmt.Errorf("%s already exists, update it from the template and translate it instead", outPath)
+		}
+	}
+
+	var target []byte
+	if !format.Bilingual() {
+		target, err = os.ReadFile(outPath)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	catalog, err := l10n.Parse(format, source, target)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if template {
+		l10n.SetLanguage(catalog, *to)
+	}
+
+	pending := l10n.Pending(catalog)
+	if len(pending) == 0 {
+		fmt.Println("All entries are translated.")
+		return nil
+	}
+	if *dryRun {
+		fmt.Printf("%d entries need to be translated:\n", len(pending))
+		for _, u := range pending {
+			fmt.Printf("  %s\n", u.Key)
+		}
+		return nil
+	}
+
+	settings := handlers.TranslateSettings{
+		SourceLang: *from,
+		TargetLang: *to,
+		Formality:  *formality,
+	}
+	var glossaries handlers.GlossariesHandler
+	if *glossary != "" {
+		id, err := resolveGlossary(translator, &glossaries, *glossary)
+		if err != nil {
+			return err
+		}
+		settings.GlossaryID = id
+	}
+
+	opts := append(settings.Options(&glossaries), deepl.WithTagHandling("xml"))
+	// don't translate XLIFF 1.2 inline elements containing native code,
+	// one option per tag since WithIgnoreTags keeps only the last tag of a list
+	for _, tag := range []string{"ph", "bpt", "ept", "it"} {
+		opts = append(opts, deepl.WithIgnoreTags([]string{tag}))
+	}
+	result, err := l10n.Translate(catalog, handlers.TextTranslator(translator, *to, opts...))
+	if err != nil {
+		return err
+	}
+
+	data, err := catalog.Bytes()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(outPath, data, 0o644); err != nil {
+		return err
+	}
+
+	fmt.Printf("Translated %d entries, written to %s\n", result.Translated, outPath)
+	if len(result.Skipped) > 0 {
+		return fmt.Errorf("%d entries lost placeholders and were not translated: %s",
+			len(result.Skipped), strings.Join(result.Skipped, ", "))
+	}
+	return nil
+}
+
+// sameFile reports whether two paths refer to the same file.
+func sameFile(a string, b string) bool {
+	if ai, err := os.Stat(a); err == nil {
+		if bi, err := os.Stat(b); err == nil {
+			return os.SameFile(ai, bi)
+		}
+	}
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
@@ -4,9 +4,53 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/cluttrdev/deepl-go/deepl"
+
+	"github.com/DeepLcom/deepl-tui/internal/api"
+	"github.com/DeepLcom/deepl-tui/internal/config"
+	"github.com/DeepLcom/deepl-tui/internal/credentials"
+	"github.com/DeepLcom/deepl-tui/internal/deeplv3"
+)
+
+// version is set at build time.
+var version = "dev"
+
+var (
+	authKeyFlag = flag.String("auth-key", "", "the authentication key as given in your DeepL account.")
+	configFlag  = flag.String("config", config.DefaultPath(), "the path of the configuration file.")
+
+	clipboardFlag      = flag.String("clipboard", "", "the clipboard backend, one of auto, system or osc52.")
+	watchClipboardFlag = flag.Bool("watch-clipboard", false, "translate text copied to the clipboard.")
+	watchWriteBackFlag = flag.Bool("watch-write-back", false, "write translations of copied text back to the clipboard.")
+	watchMaxLengthFlag = flag.Int("watch-max-length", 0, "the maximum length of copied text to translate.")
+
+	markdownFlag = flag.Bool("markdown", false, "translate the input as Markdown.")
+
+	translateModeFlag      = flag.String("translate-mode", "", "translate the input automatically (auto) or only on request (manual).")
+	translateDelayFlag     = flag.Duration("translate-delay", 0, "the time without changes after which the input is translated in auto mode.")
+	translateContextFlag   = flag.Int("translate-context", 0, "the number of unchanged paragraphs translated together with a changed paragraph.")
+	translateMinChangeFlag = flag.Int("translate-min-change", 0, "the number of characters that have to change before the input is translated again in auto mode.")
+
+	confirmCharactersFlag = flag.Int("confirm-characters", 0, "the number of characters above which a translation has to be confirmed, 0 disables the confirmation.")
+
+	serverURLFlag = flag.String("server-url", "", "the URL of the DeepL API server.")
+	proxyFlag     = flag.String("proxy", "", "the URL of the HTTP(S) proxy, by default taken from HTTPS_PROXY.")
+	caFileFlag    = flag.String("ca-file", "", "a PEM file with additional trusted CA certificates.")
+	timeoutFlag   = flag.Duration("timeout", 0, "the timeout of a single API request.")
+	userAgentFlag = flag.String("user-agent", "", "a suffix appended to the User-Agent header.")
+
+	logFileFlag     = flag.String("log-file", "", "the path of the log file, logging is disabled by default.")
+	logLevelFlag    = flag.String("log-level", "", "the minimum log level, one of debug, info, warn or error.")
+	logPayloadsFlag = flag.Bool("log-payloads", false, "log the texts and translations of requests at debug level.")
+
+	refreshIntervalFlag = flag.Duration("refresh-interval", 0, "the interval in which languages and glossaries are refreshed, 0 disables the refresh.")
 )
 
 func main() {
@@ -17,28 +61,230 @@ func main() {
 }
 
 func execute() error {
-	auth_key := parseAuthKey()
+	flag.Parse()
+
+	cfg, err := config.Load(*configFlag)
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+	applyFlags(&cfg)
+
+	logFile, err := setupLogging(cfg.Log)
+	if err != nil {
+		return err
+	}
+	if logFile != nil {
+		defer logFile.Close()
+	}
+
+	args := flag.Args()
+	if len(args) > 0 && args[0] == "auth" {
+		return runAuth(cfg, args[1:])
+	}
+
+	auth_key, source, err := parseAuthKey(cfg)
+	if err != nil {
+		return err
+	}
 	if auth_key == "" {
-		return errors.New("Missing required authentication key.")
+		return errors.New("Missing required authentication key, use `deepl-tui auth login` to store one.")
+	}
+
+	client, err := newAPIClient(cfg)
+	if err != nil {
+		return err
 	}
 
-	translator, err := deepl.NewTranslator(auth_key)
+	translator, err := newTranslator(auth_key, cfg.ServerURL, client)
 	if err != nil {
 		return err
 	}
 
-	app := NewApplication(translator)
-	return app.Run()
+	if len(args) == 0 {
+		glossaryOpts := []deeplv3.ClientOption{deeplv3.WithHTTPClient(client)}
+		if cfg.ServerURL != "" {
+			glossaryOpts = append(glossaryOpts, deeplv3.WithServerURL(strings.TrimRight(cfg.ServerURL, "/")))
+		}
+
+		glossaryClient := deeplv3.NewClient(auth_key, glossaryOpts...)
+		auth := authInfo{key: auth_key, source: source, serverURL: cfg.ServerURL}
+		app, err := NewApplication(translator, glossaryClient, cfg, auth)
+		if err != nil {
+			return err
+		}
+		client.SetRetryFunc(app.notifyRetry)
+		return app.Run()
+	}
+
+	switch args[0] {
+	case "glossary":
+		return runGlossaryCommand(translator, args[1:])
+	case "serve":
+		return runServe(translator, args[1:])
+	case "rpc":
+		return runRPC(translator, args[1:])
+	case "batch":
+		return runBatch(translator, args[1:])
+	case "l10n":
+		return runL10n(translator, args[1:])
+	case "translate":
+		return runTranslate(translator, cfg, args[1:])
+	case "subtitles":
+		return runSubtitles(translator, cfg, args[1:])
+	default:
+		return fmt.Errorf("unknown command: %s", args[0])
+	}
+}
+
+// applyFlags overrides configuration settings with explicitly set flags.
+func applyFlags(cfg *config.Config) {
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "clipboard":
+			cfg.Clipboard = *clipboardFlag
+		case "watch-clipboard":
+			cfg.ClipboardWatch.Enabled = *watchClipboardFlag
+		case "watch-write-back":
+			cfg.ClipboardWatch.WriteBack = *watchWriteBackFlag
+		case "watch-max-length":
+			cfg.ClipboardWatch.MaxLength = *watchMaxLengthFlag
+		case "markdown":
+			cfg.Markdown = *markdownFlag
+		case "translate-mode":
+			cfg.Translation.Mode = *translateModeFlag
+		case "translate-delay":
+			cfg.Translation.Delay = config.Duration(*translateDelayFlag)
+		case "translate-context":
+			cfg.Translation.ContextParagraphs = *translateContextFlag
+		case "translate-min-change":
+			cfg.Translation.MinChange = *translateMinChangeFlag
+		case "confirm-characters":
+			cfg.ConfirmCharacters = *confirmCharactersFlag
+		case "server-url":
+			cfg.ServerURL = *serverURLFlag
+		case "proxy":
+			cfg.HTTP.Proxy = *proxyFlag
+		case "ca-file":
+			cfg.HTTP.CAFile = *caFileFlag
+		case "timeout":
+			cfg.HTTP.Timeout = config.Duration(*timeoutFlag)
+		case "user-agent":
+			cfg.HTTP.UserAgent = *userAgentFlag
+		case "log-file":
+			cfg.Log.File = *logFileFlag
+		case "log-level":
+			cfg.Log.Level = *logLevelFlag
+		case "log-payloads":
+			cfg.Log.Payloads = *logPayloadsFlag
+		case "refresh-interval":
+			cfg.RefreshInterval = config.Duration(*refreshIntervalFlag)
+		}
+	})
 }
 
-func parseAuthKey() string {
+// newAPIClient creates the HTTP client used for all API requests.
+func newAPIClient(cfg config.Config) (*api.Client, error) {
+	transport, err := api.NewTransport(cfg.HTTP.Proxy, cfg.HTTP.CAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	userAgent := "deepl-tui/" + version
+	if cfg.HTTP.UserAgent != "" {
+		userAgent += " " + cfg.HTTP.UserAgent
+	}
+
+	opts := []api.Option{
+		api.WithHTTPClient(&http.Client{
+			Transport: transport,
+			Timeout:   time.Duration(cfg.HTTP.Timeout),
+		}),
+		api.WithUserAgent(userAgent),
+	}
+	if cfg.Log.File != "" {
+		opts = append(opts, api.WithLogger(slog.Default(), cfg.Log.Payloads))
+	}
+	return api.NewClient(opts...), nil
+}
+
+// setupLogging sets the default logger, which writes JSON records to the
+// configured log file. The returned file is nil if logging is disabled.
+func setupLogging(cfg config.Log) (*os.File, error) {
+	if cfg.File == "" {
+		// never write to the terminal used by the ui
+		slog.SetDefault(slog.New(slog.NewJSONHandler(io.Discard, nil)))
+		return nil, nil
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return nil, fmt.Errorf("invalid log level: %s", cfg.Level)
+	}
+
+	f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("error opening log file: %w", err)
+	}
+
+	slog.SetDefault(slog.New(slog.NewJSONHandler(f, &slog.HandlerOptions{Level: level})))
+	slog.Info("starting deepl-tui", "version", version, "args", redactArgs(os.Args[1:]))
+	return f, nil
+}
+
+// redactArgs returns the command line arguments with the value of the
+// `--auth-key` flag masked.
+func redactArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	copy(redacted, args)
+	for i, arg := range redacted {
+		name, value, ok := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if name != "auth-key" || !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if ok {
+			redacted[i] = arg[:len(arg)-len(value)] + credentials.Mask(value)
+		} else if i+1 < len(redacted) {
+			redacted[i+1] = credentials.Mask(redacted[i+1])
+		}
+	}
+	return redacted
+}
+
+// newTranslator creates the translator using the given HTTP client.
+func newTranslator(authKey string, serverURL string, client *api.Client) (*deepl.Translator, error) {
+	opts := []deepl.TranslatorOption{deepl.WithHTTPClient(client)}
+	if serverURL != "" {
+		opts = append(opts, deepl.WithServerURL(strings.TrimRight(serverURL, "/")))
+	}
+	return deepl.NewTranslator(authKey, opts...)
+}
+
+// parseAuthKey returns the authentication key and where it was found.
+// The key is looked up in the `--auth-key` flag, the DEEPL_AUTH_KEY
+// environment variable, the output of the credential command and the
+// credential stores, in this order. An empty key is returned if none is
+// found.
+func parseAuthKey(cfg config.Config) (string, string, error) {
 	// parse args
-	f := flag.String("auth-key", "", "the authentication key as given in your DeepL account.")
-	flag.Parse()
-	if f != nil && *f != "" {
-		return *f
+	if *authKeyFlag != "" {
+		return *authKeyFlag, "--auth-key", nil
 	}
 
 	// parse env
-	return os.Getenv("DEEPL_AUTH_KEY")
+	if key := os.Getenv("DEEPL_AUTH_KEY"); key != "" {
+		// don't pass the key on to child processes like the editor
+		os.Unsetenv("DEEPL_AUTH_KEY")
+		return key, "DEEPL_AUTH_KEY", nil
+	}
+
+	if cfg.CredentialCommand != "" {
+		key, err := credentials.Command(cfg.CredentialCommand)
+		return key, "credential command", err
+	}
+
+	key, source, err := credentials.Lookup(credentials.Stores(readPassphrase))
+	if errors.Is(err, credentials.ErrNotFound) {
+		return "", "", nil
+	}
+	return key, source, err
 }
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"unicode/utf8"
+)
+
+// setupTranslateMode registers the `mode` command, the mode button and the
+// `alt-enter` key binding, which translates the input right away.
+func (app *Application) setupTranslateMode() {
+	app.ui.SetManualMode(app.manual)
+
+	app.ui.SetCommandFunc("mode", func(args []string) error {
+		switch {
+		case len(args) == 0:
+			app.setManualMode(!app.manual)
+		case len(args) == 1 && args[0] == "auto":
+			app.setManualMode(false)
+		case len(args) == 1 && args[0] == "manual":
+			app.setManualMode(true)
+		default:
+			return errors.New("usage: mode [auto|manual]")
+		}
+
+		if app.manual {
+			return errors.New("Manual mode, press alt-enter to translate")
+		}
+		return errors.New("Auto mode")
+	})
+
+	app.ui.SetTranslateModeToggledFunc(func() {
+		app.setManualMode(!app.manual)
+	})
+
+	app.ui.SetTranslateFunc(app.updateTranslation)
+}
+
+// setManualMode switches between auto and manual mode. When switching to auto
+// mode, a pending change of the input is translated.
+func (app *Application) setManualMode(manual bool) {
+	app.manual = manual
+	app.ui.SetManualMode(manual)
+	if !manual {
+		app.inputChanged()
+	}
+}
+
+// autoTranslate updates the translation after a setting changed, unless the
+// input is only translated on request.
+func (app *Application) autoTranslate() {
+	if app.manual {
+		return
+	}
+	app.updateTranslation()
+}
+
+// inputChanged is called when the input has not changed for the configured
+// delay. In auto mode, the input is translated if enough characters changed
+// since the last translation.
+func (app *Application) inputChanged() {
+	if app.manual {
+		return
+	}
+
+	text := app.ui.GetInputText()
+	if text != "" && changedCharacters(app.translatedText, text) < app.config.Translation.MinChange {
+		return
+	}
+	app.updateTranslation()
+}
+
+// changedCharacters returns the number of characters that differ between `a`
+// and `b` after removing their common prefix and suffix.
+func changedCharacters(a string, b string) int {
+	for a != "" && b != "" {
+		ra, na := utf8.DecodeRuneInString(a)
+		rb, nb := utf8.DecodeRuneInString(b)
+		if ra != rb {
+			break
+		}
+		a, b = a[na:], b[nb:]
+	}
+	for a != "" && b != "" {
+		ra, na := utf8.DecodeLastRuneInString(a)
+		rb, nb := utf8.DecodeLastRuneInString(b)
+		if ra != rb {
+			break
+		}
+		a, b = a[:len(a)-na], b[:len(b)-nb]
+	}
+	return max(utf8.RuneCountInString(a), utf8.RuneCountInString(b))
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"github.com/cluttrdev/deepl-go/deepl"
+
+	"github.com/DeepLcom/deepl-tui/internal/rpc"
+)
+
+// runRPC serves JSON-RPC requests on stdin and writes responses to stdout.
+func runRPC(translator *deepl.Translator, args []string) error {
+	if len(args) > 0 {
+		return errors.New("usage: deepl-tui rpc")
+	}
+	return rpc.NewServer(translator).Serve(os.Stdin, os.Stdout)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/cluttrdev/deepl-go/deepl"
+
+	"github.com/DeepLcom/deepl-tui/internal/server"
+)
+
+// runServe serves the local HTTP/JSON API until interrupted.
+func runServe(translator *deepl.Translator, args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	listen := flags.String("listen", "127.0.0.1:8089", "the address to listen on.")
+	allowRemote := flags.Bool("allow-remote", false, "allow listening on an address that is not a loopback address.")
+	token := flags.String("token", "", "require this bearer token in the Authorization header, by default taken from DEEPL_TUI_SERVE_TOKEN.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *token == "" {
+		*token = os.Getenv("DEEPL_TUI_SERVE_TOKEN")
+	}
+
+	if !server.IsLoopback(*listen) {
+		if !*allowRemote {
+			return fmt.Errorf("%s is not a loopback address, use --allow-remote to serve other machines", *listen)
+		}
+		if *token == "" {
+			fmt.Fprintln(os.Stderr, "Warning: serving other machines without --token, anyone who can connect can use your DeepL account")
+		}
+	}
+
+	opts := server.Options{
+		Addr:  *listen,
+		Token: *token,
+	}
+	srv := &http.Server{
+		Addr:              *listen,
+		Handler:           server.New(translator, opts).Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		fmt.Fprintf(os.Stderr, "Listening on http://%s\n", *listen)
+		errc <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
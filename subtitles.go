@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cluttrdev/deepl-go/deepl"
+
+	"github.com/DeepLcom/deepl-tui/internal/config"
+	"github.com/DeepLcom/deepl-tui/internal/handlers"
+	"github.com/DeepLcom/deepl-tui/internal/subtitles"
+)
+
+// runSubtitles translates an SRT or WebVTT subtitle file.
+func runSubtitles(translator *deepl.Translator, cfg config.Config, args []string) error {
+	const usage = "usage: deepl-tui subtitles --to LANG [--from LANG] [--merge] [--out PATH] FILE"
+
+	flags := flag.NewFlagSet("subtitles", flag.ContinueOnError)
+	to := flags.String("to", "", "the target language.")
+	from := flags.String("from", "", "the source language, detected if not set.")
+	formality := flags.String("formality", "", "the formality of the translation.")
+	glossary := flags.String("glossary", "", "the name or id of the glossary to use.")
+	merge := flags.Bool("merge", cfg.Subtitles.Merge, "translate cues that continue a sentence together.")
+	out := flags.String("out", "", "the output file (default `<name>.<lang>.<ext>` next to the input file).")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *to == "" || flags.NArg() != 1 {
+		return errors.New(usage)
+	}
+
+	path := flags.Arg(0)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	subs, err := subtitles.Parse(string(data))
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	settings := handlers.TranslateSettings{
+		SourceLang: *from,
+		TargetLang: *to,
+		Formality:  *formality,
+	}
+	var glossaries handlers.GlossariesHandler
+	if *glossary != "" {
+		id, err := resolveGlossary(translator, &glossaries, *glossary)
+		if err != nil {
+			return err
+		}
+		settings.GlossaryID = id
+	}
+
+	sopts := subtitles.DefaultOptions()
+	sopts.Merge = *merge
+	if err := handlers.TranslateSubtitles(translator, subs, *to, sopts, settings.Options(&glossaries)...); err != nil {
+		return err
+	}
+
+	outPath := *out
+	if outPath == "" {
+		ext := filepath.Ext(path)
+		outPath = strings.TrimSuffix(path, ext) + "." + strings.ToLower(*to) + ext
+	}
+	if err := os.WriteFile(outPath, []byte(subs.String()), 0o644); err != nil {
+		return err
+	}
+
+	fmt.Printf("Translated %d cues, written to %s\n", len(subs.Cues()), outPath)
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cluttrdev/deepl-go/deepl"
+
+	"github.com/DeepLcom/deepl-tui/internal/config"
+	"github.com/DeepLcom/deepl-tui/internal/handlers"
+)
+
+// runTranslate translates a file or stdin without starting the user
+// interface and writes the translation to stdout.
+func runTranslate(translator *deepl.Translator, cfg config.Config, args []string) error {
+	const usage = "usage: deepl-tui translate --to LANG [--from LANG] [--markdown] [FILE]"
+
+	flags := flag.NewFlagSet("translate", flag.ContinueOnError)
+	to := flags.String("to", "", "the target language.")
+	from := flags.String("from", "", "the source language, detected if not set.")
+	formality := flags.String("formality", "", "the formality of the translation.")
+	glossary := flags.String("glossary", "", "the name or id of the glossary to use.")
+	markdown := flags.Bool("markdown", cfg.Markdown, "translate the input as Markdown (default for .md files).")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *to == "" || flags.NArg() > 1 {
+		return errors.New(usage)
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	if path := flags.Arg(0); path != "" && path != "-" {
+		data, err = os.ReadFile(path)
+		if !isFlagSet(flags, "markdown") && isMarkdownFile(path) {
+			*markdown = true
+		}
+	} else {
+		data, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return err
+	}
+
+	settings := handlers.TranslateSettings{
+		SourceLang: *from,
+		TargetLang: *to,
+		Formality:  *formality,
+	}
+	var glossaries handlers.GlossariesHandler
+	if *glossary != "" {
+		id, err := resolveGlossary(translator, &glossaries, *glossary)
+		if err != nil {
+			return err
+		}
+		settings.GlossaryID = id
+	}
+	opts := settings.Options(&glossaries)
+
+	var output string
+	if *markdown {
+		output, err = handlers.TranslateMarkdown(translator, string(data), *to, opts...)
+	} else {
+		var translations []string
+		translations, err = handlers.TextTranslator(translator, *to, opts...)([]string{string(data)})
+		output = strings.Join(translations, "")
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprint(os.Stdout, output)
+	return err
+}
+
+// resolveGlossary fetches the glossaries and returns the id of the glossary
+// with the given name or id.
+func resolveGlossary(translator *deepl.Translator, glossaries *handlers.GlossariesHandler, glossary string) (string, error) {
+	if err := glossaries.FetchGlossaries(translator); err != nil {
+		return "", err
+	}
+	if id := glossaries.FindName(glossary); id != "" {
+		return id, nil
+	}
+	if _, ok := glossaries.Get(glossary); ok {
+		return glossary, nil
+	}
+	return "", fmt.Errorf("unknown glossary: %s", glossary)
+}
+
+func isFlagSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		set = set || f.Name == name
+	})
+	return set
+}
+
+func isMarkdownFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".md" || ext == ".markdown"
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/DeepLcom/deepl-tui/internal/clipboard"
+	"github.com/DeepLcom/deepl-tui/internal/config"
+)
+
+// clipboardWatch polls the clipboard and puts newly copied text into the
+// input text area to have it translated.
+type clipboardWatch struct {
+	clipboard clipboard.Backend
+
+	mu        sync.Mutex
+	enabled   bool
+	paused    bool
+	writeBack bool
+	maxLength int
+
+	last   string // last seen clipboard content
+	seen   bool   // whether last has been read since watching was enabled
+	copied string // last copied text that was put into the input
+}
+
+func newClipboardWatch(cb clipboard.Backend, cfg config.ClipboardWatch) *clipboardWatch {
+	return &clipboardWatch{
+		clipboard: cb,
+
+		enabled:   cfg.Enabled,
+		writeBack: cfg.WriteBack,
+		maxLength: cfg.MaxLength,
+	}
+}
+
+// setupClipboardWatch starts polling the clipboard and registers the `watch`
+// command. The ui copies to the clipboard through the watch, so that text
+// copied in the ui is not put back into the input.
+func (app *Application) setupClipboardWatch(cfg config.ClipboardWatch) {
+	app.watch = newClipboardWatch(app.clipboard, cfg)
+	app.ui.SetClipboardBackend(app.watch)
+
+	app.ui.SetCommandFunc("watch", func(args []string) error {
+		if len(args) > 1 {
+			return errors.New("usage: watch [on|off|pause|resume]")
+		}
+
+		w := app.watch
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		var arg string
+		if len(args) == 1 {
+			arg = args[0]
+		} else if !w.enabled {
+			arg = "on"
+		} else if w.paused {
+			arg = "resume"
+		} else {
+			arg = "pause"
+		}
+
+		switch arg {
+		case "on":
+			if !w.enabled {
+				w.seen = false
+			}
+			w.enabled, w.paused = true, false
+		case "off":
+			w.enabled = false
+		case "pause":
+			w.paused = true
+		case "resume":
+			w.paused = false
+		default:
+			return errors.New("usage: watch [on|off|pause|resume]")
+		}
+		return errors.New(w.status())
+	})
+
+	go func() {
+		ticker := time.NewTicker(500 * time.Millisecond)
+		defer ticker.Stop()
+		for range ticker.C {
+			app.pollClipboard()
+		}
+	}()
+}
+
+func (app *Application) pollClipboard() {
+	w := app.watch
+	w.mu.Lock()
+	if !w.enabled || w.paused {
+		w.mu.Unlock()
+		return
+	}
+
+	text, err := w.clipboard.Paste()
+	if err != nil {
+		// report the error once and pause, else it would show up every time
+		w.paused = true
+		w.mu.Unlock()
+		app.ui.QueueUpdateDraw(func() {
+			app.ui.SetFooter(fmt.Sprintf("Clipboard watch paused, cannot read %s clipboard: %v", w.clipboard.Name(), err))
+		})
+		return
+	}
+	if !w.seen {
+		// only translate text copied after watching was enabled
+		w.last, w.seen = text, true
+		w.mu.Unlock()
+		return
+	}
+	if text == w.last || strings.TrimSpace(text) == "" {
+		w.last = text
+		w.mu.Unlock()
+		return
+	}
+	w.last = text
+
+	tooLong := w.maxLength > 0 && utf8.RuneCountInString(text) > w.maxLength
+	if !tooLong {
+		w.copied = text
+	}
+	maxLength := w.maxLength
+	w.mu.Unlock()
+
+	// don't hold the lock while waiting for the ui
+	app.ui.QueueUpdateDraw(func() {
+		if tooLong {
+			app.ui.SetFooter(fmt.Sprintf("Clipboard text not translated, it exceeds %d characters", maxLength))
+			return
+		}
+		app.ui.SetInputText(text)
+		if app.manual {
+			// copying text is a request to translate it
+			app.updateTranslation()
+		}
+	})
+}
+
+// translated is called when the input text has been translated. If the text
+// was copied to the clipboard and write back is enabled, the translation is
+// written to the clipboard.
+func (w *clipboardWatch) translated(text string, translation string) error {
+	if w == nil {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.enabled || w.paused || !w.writeBack || text != w.copied {
+		return nil
+	}
+	if err := w.clipboard.Copy(translation); err != nil {
+		return err
+	}
+	w.last = translation
+	return nil
+}
+
+// Name returns the name of the watched clipboard.
+func (w *clipboardWatch) Name() string {
+	return w.clipboard.Name()
+}
+
+// Copy copies the text to the watched clipboard and records it as seen, so
+// that it is not translated.
+func (w *clipboardWatch) Copy(text string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.clipboard.Copy(text); err != nil {
+		return err
+	}
+	w.last = text
+	return nil
+}
+
+// Paste returns the content of the watched clipboard.
+func (w *clipboardWatch) Paste() (string, error) {
+	return w.clipboard.Paste()
+}
+
+func (w *clipboardWatch) status() string {
+	switch {
+	case !w.enabled:
+		return "Clipboard watch off"
+	case w.paused:
+		return "Clipboard watch paused"
+	}
+	return "Clipboard watch on"
+}